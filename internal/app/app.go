@@ -6,6 +6,14 @@ import (
 	serviceAdmin "complaint_server/internal/service/admin"
 	serviceCategory "complaint_server/internal/service/category"
 	serviceComplaint "complaint_server/internal/service/complaint"
+	serviceExport "complaint_server/internal/service/export"
+	serviceNotification "complaint_server/internal/service/notification"
+	serviceOutbox "complaint_server/internal/service/outbox"
+	serviceStats "complaint_server/internal/service/stats"
+	serviceStream "complaint_server/internal/service/stream"
+	serviceSuggest "complaint_server/internal/service/suggest"
+	serviceTemplate "complaint_server/internal/service/template"
+	serviceWebhook "complaint_server/internal/service/webhook"
 	redisClient "complaint_server/internal/storage"
 	"complaint_server/internal/storage/pg"
 	"context"
@@ -16,7 +24,10 @@ import (
 )
 
 type App struct {
-	server *http.Server
+	server  *http.Server
+	workers []func(ctx context.Context)
+	ctx     context.Context
+	cancel  context.CancelFunc
 }
 
 func NewApp(ctx context.Context, cfg *config.Config, log *slog.Logger) (*App, error) {
@@ -26,20 +37,50 @@ func NewApp(ctx context.Context, cfg *config.Config, log *slog.Logger) (*App, er
 	if err != nil {
 		return nil, err
 	}
-	client, err := redisClient.NewClient(ctx, cfg, log)
-	if err != nil {
-		return nil, err
-	}
+	client, breaker := redisClient.NewClient(ctx, cfg, log)
 
-	cache := redisClient.NewRedisCache(client)
+	cache := redisClient.NewTieredCache(client, cfg, log)
+	breaker.OnRecover(cache.Resync)
 	complaintsRepo := pg.NewComplaintRepo(db)
-	categoryRepo := pg.NewCategoryRepo(db, client)
+	categoryRepo := pg.NewCategoryRepo(db)
+	webhookRepo := pg.NewWebhookRepo(db)
+	outboxRepo := pg.NewOutboxRepo(db)
+	studentRepo := pg.NewStudentRepo(db)
+	notificationRepo := pg.NewNotificationRepo(db)
+	statsRepo := pg.NewStatsRepo(db)
+	exportJobRepo := pg.NewExportJobRepo(db)
+	suggestRepo := pg.NewSuggestRepo(db)
+	templateRepo := pg.NewTemplateRepo(db)
 
-	complaintsService := serviceComplaint.NewComplaintsService(complaintsRepo, cache)
+	webhookService := serviceWebhook.NewWebhookService(webhookRepo, cfg, log)
+	complaintsService := serviceComplaint.NewComplaintsService(complaintsRepo, cache, cfg, log)
 	categoriesService := serviceCategory.NewCategoriesService(categoryRepo, cache)
 	adminService := serviceAdmin.NewAdminService(db)
+	statsService := serviceStats.NewStatsService(statsRepo, cfg, log)
+	exporter := serviceExport.NewExporter(complaintsRepo, cfg)
+	exportJobService := serviceExport.NewJobService(exportJobRepo, complaintsRepo, exporter, cfg, log)
+	suggestService := serviceSuggest.NewSuggestService(suggestRepo, categoryRepo, cfg, log)
+	templateService := serviceTemplate.NewTemplateService(templateRepo, complaintsRepo)
+
+	dispatcher := serviceOutbox.NewDispatcher(outboxRepo, cfg, log)
+	streamHub := serviceStream.NewHub(client, cfg, log)
+	dispatcher.Register("webhooks", webhookService)
+	dispatcher.Register("stream", streamHub)
+	workers := []func(ctx context.Context){breaker.Run, dispatcher.Run, webhookService.Run, streamHub.Run, cache.Run, statsService.Run, exportJobService.Run, suggestService.Run}
 
-	httpserver.RegisterRoutes(ctx, cfg, router, log, client, complaintsService, categoriesService, adminService)
+	if cfg.SMTP.Enabled {
+		mailer, err := serviceNotification.NewSMTPMailer(cfg.SMTP, config.ParseDuration(log, "SMTP_TIMEOUT", cfg.SMTP.Timeout, 10*time.Second))
+		if err != nil {
+			return nil, err
+		}
+		notifier, err := serviceNotification.NewEmailNotifier(studentRepo, notificationRepo, mailer, cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher.Register("email", notifier)
+	}
+
+	httpserver.RegisterRoutes(ctx, cfg, router, log, client, breaker, db, complaintsService, categoriesService, adminService, webhookService, streamHub, statsService, exporter, exportJobService, suggestService, templateService)
 
 	readTimeout, err := time.ParseDuration(cfg.HTTPServer.Timeout)
 	if err != nil {
@@ -58,15 +99,24 @@ func NewApp(ctx context.Context, cfg *config.Config, log *slog.Logger) (*App, er
 		WriteTimeout: writeTimeout,
 	}
 
+	workersCtx, cancel := context.WithCancel(context.Background())
+
 	return &App{
-		server: server,
+		server:  server,
+		workers: workers,
+		ctx:     workersCtx,
+		cancel:  cancel,
 	}, nil
 }
 
 func (a *App) Run() error {
+	for _, worker := range a.workers {
+		go worker(a.ctx)
+	}
 	return a.server.ListenAndServe()
 }
 
 func (a *App) Shutdown(ctx context.Context) error {
+	a.cancel()
 	return a.server.Shutdown(ctx)
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
+	"log/slog"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +13,20 @@ type Config struct {
 	JwtSecret   string `env:"JWT_SECRET" env-default:"SUPER-SECRET-CODE"`
 	RedisClient RedisClient
 	HTTPServer  HTTPServer
+	Webhooks    Webhooks
+	Outbox      Outbox
+	SMTP        SMTP
+	Stream      Stream
+	Stats       Stats
+	Export      Export
+	Similarity  Similarity
+	Endorsement Endorsement
+	Content     Content
+	Suggest     Suggest
+	Rating      Rating
+	Idempotency Idempotency
+	HTTPCache   HTTPCache
+	Cache       Cache
 }
 
 type RedisClient struct {
@@ -21,6 +37,9 @@ type RedisClient struct {
 	MaxRetries  int    `env:"REDIS_MAX_RETRIES" env-default:"3"`
 	DialTimeout string `env:"REDIS_DIAL_TIMEOUT" env-default:"5s"`
 	Timeout     string `env:"REDIS_TIMEOUT" env-default:"10s"`
+	// Consecutive connection failures after which Redis is bypassed until a probe succeeds
+	BreakerThreshold int    `env:"REDIS_BREAKER_THRESHOLD" env-default:"5"`
+	ProbeInterval    string `env:"REDIS_PROBE_INTERVAL" env-default:"5s"`
 }
 
 type HTTPServer struct {
@@ -31,6 +50,129 @@ type HTTPServer struct {
 	Password    string `env:"HTTP_PASSWORD"`
 }
 
+type Webhooks struct {
+	PollInterval   string `env:"WEBHOOK_POLL_INTERVAL" env-default:"5s"`
+	RequestTimeout string `env:"WEBHOOK_REQUEST_TIMEOUT" env-default:"10s"`
+	BaseBackoff    string `env:"WEBHOOK_BASE_BACKOFF" env-default:"30s"`
+	MaxBackoff     string `env:"WEBHOOK_MAX_BACKOFF" env-default:"6h"`
+	MaxAttempts    int    `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"10"`
+	BatchSize      int    `env:"WEBHOOK_BATCH_SIZE" env-default:"20"`
+}
+
+type Outbox struct {
+	PollInterval string `env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int    `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	BaseBackoff  string `env:"OUTBOX_BASE_BACKOFF" env-default:"1s"`
+	MaxBackoff   string `env:"OUTBOX_MAX_BACKOFF" env-default:"5m"`
+	Retention    string `env:"OUTBOX_RETENTION" env-default:"168h"`
+}
+
+type SMTP struct {
+	Enabled     bool   `env:"SMTP_ENABLED" env-default:"false"`
+	Host        string `env:"SMTP_HOST" env-default:"localhost"`
+	Port        int    `env:"SMTP_PORT" env-default:"587"`
+	Username    string `env:"SMTP_USER"`
+	Password    string `env:"SMTP_PASSWORD"`
+	From        string `env:"SMTP_FROM" env-default:"Complaints <no-reply@astanait.edu.kz>"`
+	TLS         string `env:"SMTP_TLS" env-default:"starttls"` // none, starttls or tls
+	Timeout     string `env:"SMTP_TIMEOUT" env-default:"10s"`
+	EmailDomain string `env:"SMTP_STUDENT_EMAIL_DOMAIN" env-default:"astanait.edu.kz"`
+	Locale      string `env:"SMTP_DEFAULT_LOCALE" env-default:"ru"`
+	LinkBase    string `env:"SMTP_LINK_BASE" env-default:"https://complaints-api.yeunikey.dev/complaints/"`
+}
+
+type Stream struct {
+	Channel    string `env:"STREAM_CHANNEL" env-default:"complaints:events"`
+	ReplaySize int    `env:"STREAM_REPLAY_SIZE" env-default:"500"`
+	Heartbeat  string `env:"STREAM_HEARTBEAT" env-default:"15s"`
+	// Student WebSocket connections
+	WSMaxConnections int    `env:"WS_MAX_CONNECTIONS_PER_BARCODE" env-default:"3"`
+	WSPingInterval   string `env:"WS_PING_INTERVAL" env-default:"30s"`
+}
+
+type Stats struct {
+	RefreshInterval string `env:"STATS_REFRESH_INTERVAL" env-default:"10m"`
+}
+
+type Export struct {
+	BatchSize int `env:"EXPORT_BATCH_SIZE" env-default:"500"`
+	// Key for pseudonymized barcodes; pseudonymized exports are refused when empty.
+	PseudonymKey string `env:"EXPORT_PSEUDONYM_KEY"`
+	// Background export jobs
+	Dir          string `env:"EXPORT_DIR" env-default:"./exports"`
+	Workers      int    `env:"EXPORT_WORKERS" env-default:"2"`
+	PollInterval string `env:"EXPORT_POLL_INTERVAL" env-default:"2s"`
+	Lease        string `env:"EXPORT_LEASE" env-default:"2m"`
+	LinkTTL      string `env:"EXPORT_LINK_TTL" env-default:"15m"`
+	Retention    string `env:"EXPORT_RETENTION" env-default:"72h"`
+	// Key for download link signatures; JWT_SECRET is used when empty.
+	SigningKey string `env:"EXPORT_SIGNING_KEY"`
+}
+
+type Similarity struct {
+	Threshold float64 `env:"SIMILARITY_THRESHOLD" env-default:"0.4"` // pg_trgm similarity, 0..1
+	Window    string  `env:"SIMILARITY_WINDOW" env-default:"720h"`
+	Limit     int     `env:"SIMILARITY_LIMIT" env-default:"10"`
+	FullText  bool    `env:"SIMILARITY_FULL_TEXT" env-default:"true"`
+}
+
+type Endorsement struct {
+	RateLimit  int    `env:"ENDORSE_RATE_LIMIT" env-default:"30"` // Endorse and unendorse requests per student per window
+	RateWindow string `env:"ENDORSE_RATE_WINDOW" env-default:"1h"`
+}
+
+// Content configures the processors run over complaint text. Modes are off, mask, reject or flag.
+type Content struct {
+	EmailMode      string   `env:"CONTENT_EMAIL_MODE" env-default:"mask"`
+	PhoneMode      string   `env:"CONTENT_PHONE_MODE" env-default:"mask"`
+	IINMode        string   `env:"CONTENT_IIN_MODE" env-default:"mask"`
+	ProfanityMode  string   `env:"CONTENT_PROFANITY_MODE" env-default:"flag"`
+	ProfanityLangs []string `env:"CONTENT_PROFANITY_LANGS" env-default:"ru,kk,en" env-separator:","`
+	// Extra words, one per line, added to the built-in lists.
+	ProfanityFile string `env:"CONTENT_PROFANITY_FILE"`
+	// Key for encrypting the original text of masked complaints; originals are not kept when empty.
+	OriginalKey string `env:"CONTENT_ORIGINAL_KEY"`
+	// Admin barcodes allowed to read original texts.
+	Auditors []int `env:"CONTENT_AUDITORS" env-separator:","`
+}
+
+type Suggest struct {
+	// Every replica trains its own copy of the model and keeps it in this file.
+	ModelPath       string  `env:"SUGGEST_MODEL_PATH" env-default:"./models/category_model.json"`
+	RetrainInterval string  `env:"SUGGEST_RETRAIN_INTERVAL" env-default:"24h"`
+	TrainWindow     string  `env:"SUGGEST_TRAIN_WINDOW" env-default:"8760h"`
+	MaxSamples      int     `env:"SUGGEST_MAX_SAMPLES" env-default:"50000"`
+	Limit           int     `env:"SUGGEST_LIMIT" env-default:"3"`
+	RuleWeight      float64 `env:"SUGGEST_RULE_WEIGHT" env-default:"0.5"` // Share of keyword rules in the score, 0..1
+	MinScore        float64 `env:"SUGGEST_MIN_SCORE" env-default:"0.05"`
+	RateLimit       int     `env:"SUGGEST_RATE_LIMIT" env-default:"60"` // Suggest requests per student per window
+	RateWindow      string  `env:"SUGGEST_RATE_WINDOW" env-default:"1h"`
+}
+
+type Rating struct {
+	Window string `env:"RATING_WINDOW" env-default:"168h"` // How long after resolution the owner can rate
+}
+
+type Idempotency struct {
+	TTL     string `env:"IDEMPOTENCY_TTL" env-default:"24h"`     // How long a stored response is replayed
+	LockTTL string `env:"IDEMPOTENCY_LOCK_TTL" env-default:"1m"` // Upper bound on holding a key for an in-flight request
+}
+
+// HTTPCache configures the response cache on public read routes.
+type HTTPCache struct {
+	Enabled bool   `env:"HTTP_CACHE_ENABLED" env-default:"true"`
+	TTL     string `env:"HTTP_CACHE_TTL" env-default:"1m"` // For routes without their own TTL
+	// Per-route TTLs as route:duration pairs, e.g. categories.list:5m,categories.complaints:30s.
+	RouteTTLs map[string]string `env:"HTTP_CACHE_ROUTE_TTLS" env-default:"categories.list:5m,categories.get:5m,categories.versions:5m,categories.complaints:30s"`
+}
+
+// Cache configures the in-process layer kept in front of Redis by every replica.
+type Cache struct {
+	L1Size  int    `env:"CACHE_L1_SIZE" env-default:"1000"` // Entries; 0 turns the in-process layer off
+	L1TTL   string `env:"CACHE_L1_TTL" env-default:"30s"`   // Also bounds staleness after a missed invalidation
+	Channel string `env:"CACHE_INVALIDATION_CHANNEL" env-default:"cache:invalidations"`
+}
+
 func MustLoad() *Config {
 	var cfg Config
 
@@ -39,3 +181,13 @@ func MustLoad() *Config {
 	}
 	return &cfg
 }
+
+// ParseDuration parses a duration setting such as "5s", falling back to def when it is malformed.
+func ParseDuration(log *slog.Logger, name string, value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Error("invalid "+name, slog.String("value", value), slog.String("error", err.Error()))
+		return def
+	}
+	return d
+}
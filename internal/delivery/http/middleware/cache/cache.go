@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"bytes"
+	"complaint_server/internal/config"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	StatusHeader = "X-Cache" // HIT or MISS
+	keyPrefix    = "httpcache:"
+)
+
+// storedHeaders are the response headers kept with a cached body.
+var storedHeaders = []string{"Content-Type", "ETag"}
+
+// Route describes how the responses of one route are cached.
+type Route struct {
+	Name string   // Looks up the TTL in HTTP_CACHE_ROUTE_TTLS
+	Tags []string // Writes to data with any of these tags drop the cached responses
+	// VaryByPrincipal caches per student, admin or client address, for responses that depend on
+	// who is asking. Without it every caller gets the same cached response.
+	VaryByPrincipal bool
+	// Private marks responses with personal data: proxies and CDNs are told not to keep them,
+	// while the server cache still serves them. VaryByPrincipal implies it.
+	Private bool
+}
+
+// Cache keeps successful GET responses in Redis. Keys are built from the method, path,
+// normalized query, the current versions of the route's tags and, if the route asks for it, the
+// caller, so a write that bumps a tag makes the old responses unreachable. Concurrent misses for
+// the same key run the handler once. When Redis is unavailable requests go through uncached.
+type Cache struct {
+	client     *redis.Client
+	cfg        *config.Config
+	log        *slog.Logger
+	defaultTTL time.Duration
+	group      singleflight.Group
+}
+
+func New(client *redis.Client, cfg *config.Config, log *slog.Logger) *Cache {
+	return &Cache{
+		client:     client,
+		cfg:        cfg,
+		log:        log.With(slog.String("component", "middleware/cache")),
+		defaultTTL: config.ParseDuration(log, "HTTP_CACHE_TTL", cfg.HTTPCache.TTL, time.Minute),
+	}
+}
+
+// entry is a cached response.
+type entry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Handler caches the responses of the route it wraps.
+func (c *Cache) Handler(route Route) func(http.Handler) http.Handler {
+	ttl := c.defaultTTL
+	if value, ok := c.cfg.HTTPCache.RouteTTLs[route.Name]; ok {
+		ttl = config.ParseDuration(c.log, "HTTP_CACHE_ROUTE_TTLS "+route.Name, value, c.defaultTTL)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !c.cfg.HTTPCache.Enabled || ttl <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log := c.log.With(
+				slog.String("route", route.Name),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+			// The response is stored even if the caller that computes it goes away.
+			ctx := context.WithoutCancel(r.Context())
+
+			key, err := c.key(ctx, r, route)
+			if err != nil {
+				log.Warn("response cache unavailable, handling request without it", sl.Err(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if cached, err := c.load(ctx, key); err == nil {
+				c.write(w, cached, "HIT", ttl, route)
+				return
+			} else if !errors.Is(err, redis.Nil) {
+				log.Warn("failed to read cached response", sl.Err(err))
+			}
+
+			result, _, _ := c.group.Do(key, func() (any, error) {
+				recorder := &responseRecorder{header: make(http.Header), body: new(bytes.Buffer), status: http.StatusOK}
+				next.ServeHTTP(recorder, r.WithContext(ctx))
+				fresh := entry{Status: recorder.status, Header: make(http.Header), Body: recorder.body.Bytes(), StoredAt: time.Now()}
+				for _, name := range storedHeaders {
+					if value := recorder.header.Get(name); value != "" {
+						fresh.Header.Set(name, value)
+					}
+				}
+				if fresh.Status == http.StatusOK {
+					if err := c.store(ctx, key, fresh, ttl); err != nil {
+						log.Error("failed to cache response", sl.Err(err))
+					}
+				}
+				return fresh, nil
+			})
+			c.write(w, result.(entry), "MISS", ttl, route)
+		})
+	}
+}
+
+// key identifies the response to a request under the current tag versions.
+func (c *Cache) key(ctx context.Context, r *http.Request, route Route) (string, error) {
+	parts := []string{r.Method, r.URL.Path, normalizeQuery(r.URL.RawQuery)}
+	if len(route.Tags) > 0 {
+		tagKeys := make([]string, len(route.Tags))
+		for i, tag := range route.Tags {
+			tagKeys[i] = storage.TagKey(tag)
+		}
+		versions, err := c.client.MGet(ctx, tagKeys...).Result()
+		if err != nil {
+			return "", err
+		}
+		for i, version := range versions {
+			v, _ := version.(string) // Nil until the tag is first invalidated
+			parts = append(parts, route.Tags[i]+"="+v)
+		}
+	}
+	if route.VaryByPrincipal {
+		parts = append(parts, jwt2.Principal(r, c.cfg.JwtSecret))
+	}
+	return keyPrefix + route.Name + ":" + digest(parts...), nil
+}
+
+func (c *Cache) load(ctx context.Context, key string) (entry, error) {
+	var cached entry
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return cached, err
+	}
+	err = json.Unmarshal(data, &cached)
+	return cached, err
+}
+
+func (c *Cache) store(ctx context.Context, key string, fresh entry, ttl time.Duration) error {
+	data, err := json.Marshal(fresh)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, data, ttl).Err()
+}
+
+// write sends a cached or freshly computed response with headers telling clients how long to keep it.
+func (c *Cache) write(w http.ResponseWriter, cached entry, status string, ttl time.Duration, route Route) {
+	for name, values := range cached.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(StatusHeader, status)
+	if route.VaryByPrincipal {
+		w.Header().Add("Vary", "Authorization")
+	}
+	if cached.Status == http.StatusOK {
+		age := max(time.Since(cached.StoredAt), 0)
+		scope := "public"
+		if route.Private || route.VaryByPrincipal {
+			scope = "private"
+		}
+		w.Header().Set("Cache-Control", scope+", max-age="+strconv.Itoa(int(max(ttl-age, 0).Seconds())))
+		w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	}
+	w.WriteHeader(cached.Status)
+	_, _ = w.Write(cached.Body)
+}
+
+// normalizeQuery orders query parameters by name, so ?a=1&b=2 and ?b=2&a=1 share a key.
+func normalizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder buffers the whole response, so it can be stored and handed to every caller
+// waiting on the same key. Only the first status counts, as with net/http.
+type responseRecorder struct {
+	header      http.Header
+	body        *bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (rw *responseRecorder) Header() http.Header {
+	return rw.header
+}
+
+func (rw *responseRecorder) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.status = statusCode
+		rw.wroteHeader = true
+	}
+}
+
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.body.Write(p)
+}
@@ -0,0 +1,238 @@
+package idempotency
+
+import (
+	"bytes"
+	"complaint_server/internal/config"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+	keyPrefix      = "idempotency:"
+	// maxBodySize bounds the request bodies read into memory to be fingerprinted.
+	maxBodySize = 1 << 20
+)
+
+// Only the request holding the key may finish or release it: once its lock expired another
+// request may have taken the key, and that one's record must survive.
+var (
+	completeScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1`)
+
+	releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// record is what is kept in Redis per key. Status is zero while the first request is in flight,
+// and Token tells that request's pending record from one left by another.
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	Token       string `json:"token,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// New makes POST requests with an Idempotency-Key header safe to retry. The first response
+// is stored per key and principal for IDEMPOTENCY_TTL and replayed to later requests with the
+// same key. A request that arrives while the first one is still running gets 409, and reusing
+// a key for a different request gets 422. Only final responses are stored; after a server error,
+// a rate limit or a timeout the client can retry with the same key. When Redis is unavailable
+// requests go through without the guarantee.
+func New(client *redis.Client, cfg *config.Config, log *slog.Logger) func(http.Handler) http.Handler {
+	ttl := config.ParseDuration(log, "IDEMPOTENCY_TTL", cfg.Idempotency.TTL, 24*time.Hour)
+	lockTTL := config.ParseDuration(log, "IDEMPOTENCY_LOCK_TTL", cfg.Idempotency.LockTTL, time.Minute)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log := log.With(
+				slog.String("component", "middleware/idempotency"),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+			if len(key) > maxKeyLength {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error(Header+" must be at most "+strconv.Itoa(maxKeyLength)+" characters", http.StatusBadRequest))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, response.Error("Request body is too large", http.StatusRequestEntityTooLarge))
+				return
+			}
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Failed to read request body", http.StatusBadRequest))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// The outcome is stored even if the client gives up waiting for it.
+			ctx := context.WithoutCancel(r.Context())
+			redisKey := keyPrefix + jwt2.Principal(r, cfg.JwtSecret) + ":" + digest(key)
+			fingerprint := digest(r.Method, r.URL.Path, r.URL.RawQuery, string(body))
+
+			pending, _ := json.Marshal(record{Fingerprint: fingerprint, Token: newToken()})
+			acquired, err := client.SetNX(ctx, redisKey, pending, lockTTL).Result()
+			if err != nil {
+				log.Warn("idempotency store unavailable, handling request without it", sl.Err(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !acquired {
+				replay(w, r, client, redisKey, fingerprint, log)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, body: new(bytes.Buffer), status: http.StatusOK}
+			completed := false
+			defer func() {
+				// A panic or a response that isn't final leaves nothing behind, so the key can be retried.
+				if !completed {
+					releaseScript.Run(ctx, client, []string{redisKey}, pending)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			if !final(recorder.status) {
+				return
+			}
+			stored, err := json.Marshal(record{
+				Fingerprint: fingerprint,
+				Status:      recorder.status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+			var owned int
+			if err == nil {
+				owned, err = completeScript.Run(ctx, client, []string{redisKey}, pending, stored, ttl.Milliseconds()).Int()
+			}
+			if err != nil {
+				log.Error("failed to store idempotent response", sl.Err(err))
+				return
+			}
+			if owned == 0 {
+				log.Warn("idempotency key was taken over while the request ran, response not stored",
+					slog.Duration("lock_ttl", lockTTL))
+			}
+			completed = true
+		})
+	}
+}
+
+// replay answers a request whose key is already taken.
+func replay(w http.ResponseWriter, r *http.Request, client *redis.Client, redisKey string, fingerprint string, log *slog.Logger) {
+	data, err := client.Get(r.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The first request failed and released the key between SETNX and GET.
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error("A request with this "+Header+" was just being processed, retry it", http.StatusConflict))
+		return
+	}
+	var rec record
+	if err == nil {
+		err = json.Unmarshal(data, &rec)
+	}
+	if err != nil {
+		log.Error("failed to read idempotent response", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	switch {
+	case rec.Fingerprint != fingerprint:
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(Header+" was already used for a different request", http.StatusUnprocessableEntity))
+	case rec.Status == 0:
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error("A request with this "+Header+" is still being processed", http.StatusConflict))
+	default:
+		if rec.ContentType != "" {
+			w.Header().Set("Content-Type", rec.ContentType)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(rec.Status)
+		_, _ = w.Write(rec.Body)
+	}
+}
+
+// final reports whether repeating a request would get the same response: a success, or a client
+// error other than those that pass with time.
+func final(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 200 && status < 300 || status >= 400 && status < 500
+}
+
+func newToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps the status that actually went out; later WriteHeader calls are ignored
+// by net/http and so are ignored here too.
+type responseRecorder struct {
+	http.ResponseWriter
+	body        *bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (rw *responseRecorder) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.status = statusCode
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
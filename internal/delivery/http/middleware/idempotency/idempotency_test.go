@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/shared/api/response"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/render"
+	"github.com/redis/go-redis/v9"
+)
+
+// failingHandler answers with the statuses in order, the way handlers report errors.
+func failingHandler(statuses ...int) (http.Handler, *int) {
+	calls := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		render.Status(r, status)
+		render.JSON(w, r, response.Response{Message: http.StatusText(status), StatusCode: status})
+	}), &calls
+}
+
+func testMiddleware(t *testing.T, next http.Handler) http.Handler {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cfg := &config.Config{Idempotency: config.Idempotency{TTL: "24h", LockTTL: "1m"}}
+	return New(client, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))(next)
+}
+
+func post(handler http.Handler, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/complaints", strings.NewReader(`{"message":"Нет горячей воды"}`))
+	r.Header.Set(Header, key)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRetryableResponsesAreNotStored(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			next, calls := failingHandler(status, http.StatusOK)
+			handler := testMiddleware(t, next)
+
+			if w := post(handler, "key-1"); w.Code != status {
+				t.Fatalf("first response = %d, want %d", w.Code, status)
+			}
+			w := post(handler, "key-1")
+			if w.Code != http.StatusOK || w.Header().Get(ReplayedHeader) != "" {
+				t.Fatalf("retry = %d replayed=%q, want the handler to run again", w.Code, w.Header().Get(ReplayedHeader))
+			}
+			if *calls != 2 {
+				t.Fatalf("handler ran %d times, want 2", *calls)
+			}
+		})
+	}
+}
+
+func TestFinalResponsesAreReplayed(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			next, calls := failingHandler(status, http.StatusInternalServerError)
+			handler := testMiddleware(t, next)
+
+			first := post(handler, "key-1")
+			second := post(handler, "key-1")
+			if second.Code != status || second.Header().Get(ReplayedHeader) != "true" {
+				t.Fatalf("retry = %d replayed=%q, want %d replayed", second.Code, second.Header().Get(ReplayedHeader), status)
+			}
+			if second.Body.String() != first.Body.String() {
+				t.Errorf("replayed body = %q, want %q", second.Body, first.Body)
+			}
+			if *calls != 1 {
+				t.Fatalf("handler ran %d times, want 1", *calls)
+			}
+		})
+	}
+}
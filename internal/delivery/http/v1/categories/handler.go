@@ -6,6 +6,7 @@ import (
 	serviceAdmin "complaint_server/internal/service/admin"
 	serviceCategory "complaint_server/internal/service/category"
 	serviceComplaint "complaint_server/internal/service/complaint"
+	"complaint_server/internal/shared/api/etag"
 	"complaint_server/internal/shared/api/response"
 	"complaint_server/internal/shared/logger/sl"
 	"complaint_server/internal/storage"
@@ -64,10 +65,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Title       string `json:"title" validate:"required"`
 		Description string `json:"description" validate:"required"`
 		Answer      string `json:"answer" validate:"required"`
+		Public      bool   `json:"public"`
 	}
 
 	if err := render.DecodeJSON(r.Body, &req); err != nil {
 		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, response.Response{
 			Message:    "Failed to decode request body",
 			StatusCode: http.StatusBadRequest,
@@ -81,6 +84,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		var validationErrors validator.ValidationErrors
 		if errors.As(err, &validationErrors) {
 			log.Error("validation failed", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, response.Response{
 				Message:    "Validation failed",
 				StatusCode: http.StatusBadRequest,
@@ -89,6 +93,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Error("unknown validation error", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, response.Response{
 			Message:    "Unknown validation error",
 			StatusCode: http.StatusBadRequest,
@@ -101,25 +106,31 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Title:       req.Title,
 		Description: req.Description,
 		Answer:      req.Answer,
+		Public:      req.Public,
 	})
 	if errors.Is(err, storage.ErrDBConnection) {
 		log.Error("db connection error", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, response.Response{
 			Message:    storage.ErrDBConnection.Error(),
 			StatusCode: http.StatusInternalServerError,
 			Data:       nil,
 		})
+		return
 	}
 	if errors.Is(err, storage.ErrCreateCategory) {
 		log.Error("failed to create categories", sl.Err(err))
+		render.Status(r, http.StatusConflict)
 		render.JSON(w, r, response.Response{
 			Message:    "Error ",
 			StatusCode: http.StatusConflict,
 			Data:       nil,
 		})
+		return
 	}
 	if err != nil {
 		log.Error("failed to save categories", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, response.Response{
 			Message:    "Failed to save categories",
 			StatusCode: http.StatusInternalServerError,
@@ -194,6 +205,7 @@ func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 // GetById New @Summary Получить категорию по ID
 // @Description Возвращает категорию по уникальному идентификатору (ID).
+// @Description Заголовок ETag содержит версию категории для If-Match при изменении и удалении.
 // @Tags Categories
 // @Accept json
 // @Produce json
@@ -250,6 +262,7 @@ func (h *Handler) GetById(w http.ResponseWriter, r *http.Request) {
 		StatusCode: http.StatusOK,
 		Data:       result,
 	})
+	etag.Set(w, result.Version)
 	w.WriteHeader(http.StatusOK)
 	w.Write(responseData)
 }
@@ -261,6 +274,7 @@ func (h *Handler) GetById(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Category UUID (unique identifier of the categories)"
+// @Param sort query string false "endorsements puts the most endorsed complaints first" Enums(endorsements)
 // @Success 200 {array} domain.Complaint "List of complaints associated with the given categories"
 // @Failure 400 {object} response.Response "Invalid categories ID format"
 // @Failure 404 {object} response.Response "No complaints found for the given categories"
@@ -290,7 +304,7 @@ func (h *Handler) GetCategoryComplaints(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := h.ComplaintService.GetComplaintsByCategoryId(r.Context(), categoryUUID)
+	result, err := h.ComplaintService.GetComplaintsByCategoryId(r.Context(), categoryUUID, domain.ParseComplaintOrder(r.URL.Query().Get("sort")))
 	if err != nil {
 		log.Error("failed to get complaints", sl.Err(err))
 		responseData, _ := json.Marshal(response.Response{
@@ -316,12 +330,16 @@ func (h *Handler) GetCategoryComplaints(w http.ResponseWriter, r *http.Request)
 
 // Update New @Summary Обновить категорию
 // @Description Обновляет информацию о категории жалоб. Требуется предоставить ID категории и новые данные (название, описание и ответ).
+// @Description If-Match должен содержать ETag из GET /categories/{id}; если категорию уже изменили, 412 вернёт её текущее состояние и ETag.
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Param If-Match header string true "ETag категории"
 // @Param request body Request true "Данные категории"
 // @Success 200 {object} response.Response "Категория успешно обновлена"
 // @Failure 400 {object} response.Response "Ошибка валидации или декодирования данных"
+// @Failure 412 {object} response.Response "Категория изменена, в data её текущая версия"
+// @Failure 428 {object} response.Response "Нет заголовка If-Match"
 // @Failure 500 {object} response.Response "Ошибка сервера"
 // @Router /admin/categories/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
@@ -336,6 +354,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		Title       string    `json:"title" validate:"required"`
 		Description string    `json:"description" validate:"required"`
 		Answer      string    `json:"answer" validate:"required"`
+		Public      *bool     `json:"public"` // Unchanged when omitted
 	}
 	if err := render.DecodeJSON(r.Body, &req); err != nil {
 		log.Error("failed to decode request body", sl.Err(err))
@@ -374,12 +393,31 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	id, err := uuid.Parse(categoryId)
+	if err != nil {
+		render.JSON(w, r, response.Response{StatusCode: http.StatusBadRequest, Message: "Invalid categories id"})
+		return
+	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
+	public := false
+	if req.Public != nil {
+		public = *req.Public
+	} else if current, err := h.CategoryService.GetCategoryById(r.Context(), id); err == nil {
+		public = current.Public
+	}
 	_, err = h.CategoryService.UpdateCategory(r.Context(), id, domain.Category{
 		ID:          req.Id,
 		Title:       req.Title,
 		Description: req.Description,
 		Answer:      req.Answer,
-	})
+		Public:      public,
+	}, expectedVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		h.versionConflict(w, r, id)
+		return
+	}
 	if errors.Is(err, storage.ErrCategoryNotFound) {
 		log.Error(op, sl.Err(err))
 		responseData, _ := json.Marshal(response.Response{
@@ -401,6 +439,9 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updated, err := h.CategoryService.GetCategoryById(r.Context(), id); err == nil {
+		etag.Set(w, updated.Version)
+	}
 	render.JSON(w, r, response.Response{
 		Message:    "Category updated successfully",
 		StatusCode: http.StatusOK,
@@ -408,6 +449,18 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Delete New @Summary Удалить категорию
+// @Description If-Match должен содержать ETag из GET /categories/{id}; если категорию уже изменили, 412 вернёт её текущее состояние и ETag.
+// @Tags Categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param If-Match header string true "ETag категории"
+// @Success 200 {object} response.Response "Категория удалена"
+// @Failure 404 {object} response.Response "Категория не найдена"
+// @Failure 409 {object} response.Response "Есть связанные жалобы"
+// @Failure 412 {object} response.Response "Категория изменена, в data её текущая версия"
+// @Failure 428 {object} response.Response "Нет заголовка If-Match"
+// @Router /categories/admin/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.complaint.deleteByAdmin.New"
 
@@ -429,7 +482,16 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.CategoryService.DeleteCategoryById(r.Context(), uuid_)
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.CategoryService.DeleteCategoryById(r.Context(), uuid_, expectedVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		h.versionConflict(w, r, uuid_)
+		return
+	}
 
 	if errors.Is(err, storage.ErrCategoryNotFound) {
 		log.Error("categories not found", sl.Err(err))
@@ -452,3 +514,20 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	log.Info("categories deleted")
 	render.JSON(w, r, response.Response{StatusCode: http.StatusOK})
 }
+
+// versionConflict answers a write whose If-Match missed with the category as it is now.
+func (h *Handler) versionConflict(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	current, err := h.CategoryService.GetCategoryById(r.Context(), id)
+	if errors.Is(err, storage.ErrCategoryNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Category not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		h.Log.Error("failed to get category", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+	etag.PreconditionFailed(w, r, current.Version, current)
+}
@@ -0,0 +1,154 @@
+package categories
+
+import (
+	serviceCategory "complaint_server/internal/service/category"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxImportSize = 1 << 20
+
+// documentFormat picks json or yaml from the format query parameter or the Content-Type header.
+func documentFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// Export @Summary Экспортировать категории
+// @Description Возвращает все категории с ответами в виде YAML или JSON документа, который принимает импорт.
+// @Tags Categories
+// @Produce application/yaml
+// @Produce json
+// @Param format query string false "Формат документа" Enums(yaml, json) default(yaml)
+// @Param include_archived query bool false "Включить архивные категории"
+// @Success 200 {object} domain.CategoryDocument "Документ категорий"
+// @Failure 500 {object} response.Response "Ошибка сервера"
+// @Router /categories/admin/export [get]
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.categories.export.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	format := documentFormat(r)
+	if format != "json" && format != "yaml" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("format must be yaml or json", http.StatusBadRequest))
+		return
+	}
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
+
+	categories, err := h.CategoryService.ExportCategories(r.Context(), includeArchived)
+	if err != nil {
+		log.Error("failed to get categories", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	data, err := serviceCategory.EncodeDocument(categories, format)
+	if err != nil {
+		log.Error("failed to encode categories", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	contentType := "application/yaml; charset=utf-8"
+	if format == "json" {
+		contentType = "application/json; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="categories.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// Import @Summary Импортировать категории
+// @Description Загружает YAML или JSON документ категорий и обновляет их по названию в одной транзакции.
+// @Description С archive_missing=true категории, которых нет в документе, архивируются. С dry_run=true возвращается только список изменений.
+// @Description Если хотя бы одна строка некорректна, ничего не сохраняется, а в ответе перечислены ошибки по строкам.
+// @Tags Categories
+// @Accept application/yaml
+// @Accept json
+// @Produce json
+// @Param request body domain.CategoryDocument true "Документ категорий"
+// @Param format query string false "Формат документа, по умолчанию из Content-Type" Enums(yaml, json)
+// @Param dry_run query bool false "Только показать изменения"
+// @Param archive_missing query bool false "Архивировать категории, которых нет в документе" default(false)
+// @Success 200 {object} domain.CategoryImportResult "Результат импорта"
+// @Failure 400 {object} response.Response "Некорректный документ"
+// @Failure 422 {object} domain.CategoryImportResult "Ошибки в строках документа"
+// @Failure 500 {object} response.Response "Ошибка сервера"
+// @Router /categories/admin/import [post]
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.categories.import.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	query := r.URL.Query()
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+	// Archiving is opt-in, so importing a partial document can't archive the rest by accident.
+	archiveMissing, _ := strconv.ParseBool(query.Get("archive_missing"))
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		log.Error("failed to read request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to read request body", http.StatusBadRequest))
+		return
+	}
+
+	doc, err := serviceCategory.DecodeDocument(data, documentFormat(r))
+	if err != nil {
+		log.Error("failed to decode category document", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	result, err := h.CategoryService.ImportCategories(r.Context(), doc, archiveMissing, dryRun)
+	if err != nil {
+		log.Error("failed to import categories", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+	if len(result.Errors) > 0 {
+		log.Warn("category import rejected", slog.Int("errors", len(result.Errors)))
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Response{
+			Message:    "Category document has invalid rows",
+			StatusCode: http.StatusUnprocessableEntity,
+			Data:       result,
+		})
+		return
+	}
+
+	log.Info("categories imported",
+		slog.Bool("dry_run", dryRun),
+		slog.Int("created", result.Created),
+		slog.Int("updated", result.Updated),
+		slog.Int("archived", result.Archived),
+	)
+	render.JSON(w, r, response.Response{
+		Message:    "Categories imported successfully",
+		StatusCode: http.StatusOK,
+		Data:       result,
+	})
+}
@@ -0,0 +1,118 @@
+package categories
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/etag"
+	"complaint_server/internal/shared/api/mergepatch"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// patchableFields are the category fields a merge patch may change; none of them can be removed.
+var patchableFields = []string{"title", "description", "answer", "public"}
+
+// PatchRequest is a JSON merge patch of a category.
+type PatchRequest struct {
+	Title       *string `json:"title" validate:"omitempty,min=1" example:"Общежитие"`
+	Description *string `json:"description" validate:"omitempty,min=1"`
+	Answer      *string `json:"answer" validate:"omitempty,min=1"`
+	Public      *bool   `json:"public"`
+}
+
+// Patch New @Summary Частично обновить категорию
+// @Description Меняет только переданные поля (title, description, answer, public) по JSON Merge Patch. Остальные поля остаются прежними, удалить поле через null нельзя.
+// @Description If-Match должен содержать ETag из GET /categories/{id}; если категорию уже изменили, 412 вернёт её текущее состояние и ETag.
+// @Description ETag меняется при любом изменении, а новая запись в /categories/{id}/versions появляется, только если изменились title, description или answer: смена одного public её не создаёт.
+// @Tags Categories
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param If-Match header string true "ETag категории"
+// @Param request body PatchRequest true "Изменяемые поля"
+// @Success 200 {object} domain.Category "Обновлённая категория"
+// @Failure 400 {object} response.Response "Неверный патч или значение поля"
+// @Failure 404 {object} response.Response "Категория не найдена"
+// @Failure 409 {object} response.Response "Категория с таким названием уже есть"
+// @Failure 412 {object} response.Response "Категория изменена, в data её текущая версия"
+// @Failure 415 {object} response.Response "Тело не application/merge-patch+json"
+// @Failure 428 {object} response.Response "Нет заголовка If-Match"
+// @Failure 500 {object} response.Response "Ошибка сервера"
+// @Router /categories/admin/{id} [patch]
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.categories.patch.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid categories id", http.StatusBadRequest))
+		return
+	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
+
+	var req PatchRequest
+	present, err := mergepatch.Decode(r, &req, patchableFields)
+	if err != nil {
+		mergepatch.WriteError(w, r, err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+
+	category, err := h.CategoryService.PatchCategory(r.Context(), id, domain.CategoryPatch{
+		Title:       req.Title,
+		Description: req.Description,
+		Answer:      req.Answer,
+		Public:      req.Public,
+	}, expectedVersion)
+	switch {
+	case errors.Is(err, storage.ErrVersionMismatch):
+		h.versionConflict(w, r, id)
+		return
+	case errors.Is(err, storage.ErrCategoryNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Category not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrCategoryTitleTaken):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	case err != nil:
+		log.Error("failed to patch category", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("Failed to update categories", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("category patched", slog.Any("id", id), slog.Any("fields", present))
+	etag.Set(w, category.Version)
+	render.JSON(w, r, response.Response{
+		Message:    "Category updated successfully",
+		StatusCode: http.StatusOK,
+		Data:       category,
+	})
+}
@@ -2,14 +2,22 @@ package categories
 
 import (
 	"complaint_server/internal/delivery/http/middleware/admin"
+	"complaint_server/internal/delivery/http/middleware/cache"
+	"complaint_server/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
 func RegisterRoutes(r chi.Router, h *Handler) {
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/", h.Create)
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Put("/admin/{id}", h.Update)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Patch("/admin/{id}", h.Patch)
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Delete("/admin/{id}", h.Delete)
-	r.Get("/", h.GetAll)
-	r.Get("/{id}", h.GetById)
-	r.Get("/{id}/complaints", h.GetCategoryComplaints)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/export", h.Export)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/import", h.Import)
+
+	responses := cache.New(h.Redis, h.Cfg, h.Log)
+	r.With(responses.Handler(cache.Route{Name: "categories.list", Tags: []string{storage.TagCategories}})).Get("/", h.GetAll)
+	r.With(responses.Handler(cache.Route{Name: "categories.get", Tags: []string{storage.TagCategories}})).Get("/{id}", h.GetById)
+	r.With(responses.Handler(cache.Route{Name: "categories.complaints", Tags: []string{storage.TagCategories, storage.TagComplaints}, Private: true})).Get("/{id}/complaints", h.GetCategoryComplaints)
+	r.With(responses.Handler(cache.Route{Name: "categories.versions", Tags: []string{storage.TagCategories}})).Get("/{id}/versions", h.GetVersions)
 }
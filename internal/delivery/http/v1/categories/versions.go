@@ -0,0 +1,63 @@
+package categories
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// GetVersions @Summary История изменений категории
+// @Description Возвращает все версии названия, описания и автоответа категории, начиная с последней.
+// @Description Эти версии не совпадают с ETag категории: смена одного флага public меняет ETag, но не добавляет версию.
+// @Description В деталях жалобы поле category_version показывает версию, действовавшую при её подаче.
+// @Tags Categories
+// @Produce json
+// @Param id path string true "ID категории"
+// @Success 200 {array} domain.CategoryVersion "Версии категории"
+// @Failure 400 {object} response.Response "Неверный формат ID"
+// @Failure 404 {object} response.Response "Категория не найдена"
+// @Failure 500 {object} response.Response "Ошибка сервера"
+// @Router /categories/{id}/versions [get]
+func (h *Handler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.categories.get_versions.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid category ID", http.StatusBadRequest))
+		return
+	}
+
+	versions, err := h.CategoryService.GetVersions(r.Context(), id)
+	if errors.Is(err, storage.ErrCategoryNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Category not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get category versions", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	if versions == nil {
+		versions = []domain.CategoryVersion{}
+	}
+	render.JSON(w, r, response.Response{
+		Message:    "Category versions fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       versions,
+	})
+}
@@ -0,0 +1,84 @@
+package complaints
+
+import (
+	"complaint_server/internal/shared/api/etag"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+type BoardRequest struct {
+	Hidden bool `json:"hidden" example:"true"`
+}
+
+// SetBoardVisibility godoc
+// @Summary Hide a complaint from the public board
+// @Description Hides the complaint from GET /public/complaints, or shows it again. If-Match must carry the ETag of the complaint.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Param id path string true "Complaint ID"
+// @Param If-Match header string true "ETag of the complaint"
+// @Param request body BoardRequest true "Visibility"
+// @Success 200 {object} response.Response "Visibility changed"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 412 {object} response.Response "Complaint changed, data holds the current version"
+// @Failure 428 {object} response.Response "If-Match header missing"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/{id}/board [put]
+func (h Handler) SetBoardVisibility(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.board.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid complaint ID", http.StatusBadRequest))
+		return
+	}
+	var req BoardRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := h.ComplaintService.SetHiddenFromBoard(r.Context(), id, req.Hidden, expectedVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		h.versionConflict(w, r, id)
+		return
+	}
+	if errors.Is(err, storage.ErrComplaintNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		log.Error("failed to change board visibility", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("board visibility changed", slog.Any("id", id), slog.Bool("hidden", req.Hidden))
+	etag.Set(w, version)
+	render.JSON(w, r, response.Response{
+		Message:    "Board visibility changed",
+		StatusCode: http.StatusOK,
+	})
+}
@@ -0,0 +1,162 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	serviceComplaint "complaint_server/internal/service/complaint"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type BulkFilter struct {
+	Status     string     `json:"status" validate:"omitempty,oneof=pending approved rejected" example:"pending"`
+	CategoryID *uuid.UUID `json:"category_id"`
+	From       string     `json:"from" validate:"omitempty,datetime=2006-01-02" example:"2025-04-21"` // Created on or after this day
+	To         string     `json:"to" validate:"omitempty,datetime=2006-01-02" example:"2025-04-21"`   // Created on or before this day
+}
+
+func (f BulkFilter) empty() bool {
+	return f.Status == "" && f.CategoryID == nil && f.From == "" && f.To == ""
+}
+
+func (f BulkFilter) complaintFilter() domain.ComplaintFilter {
+	filter := domain.ComplaintFilter{Status: f.Status, CategoryID: f.CategoryID}
+	if from, err := time.Parse(statsDateLayout, f.From); err == nil {
+		filter.From = &from
+	}
+	if to, err := time.Parse(statsDateLayout, f.To); err == nil {
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	return filter
+}
+
+type BulkRequest struct {
+	IDs    []uuid.UUID `json:"ids" validate:"max=500"`
+	Filter *BulkFilter `json:"filter"`
+	Status string      `json:"status" validate:"required,oneof=pending approved rejected" example:"approved"`
+	Answer string      `json:"answer" example:"Hot water is back, thank you for reporting"`
+	// Renders the answer for each complaint instead of using answer.
+	TemplateID *uuid.UUID        `json:"template_id"`
+	Variables  map[string]string `json:"variables" example:"room:C-214"`
+}
+
+// Bulk godoc
+// @Summary Change the status of many complaints
+// @Description Applies a status and a shared answer to the listed complaints, or to the complaints matching the filter, in one transaction.
+// @Description Complaints whose current status doesn't allow the change, and complaints merged into another one, are skipped. Each updated complaint gets an audit entry.
+// @Description With template_id, each complaint gets its own answer rendered from the template; complaints the template can't answer, such as ones of another category, are skipped.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Param request body BulkRequest true "Either ids or filter, and the new status"
+// @Success 200 {object} domain.BulkResult "Per-complaint results"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 413 {object} response.Response "The filter matches too many complaints"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/bulk [post]
+func (h Handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.bulk.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req BulkRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			log.Error("validation failed", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+
+	hasFilter := req.Filter != nil && !req.Filter.empty()
+	if (len(req.IDs) > 0) == hasFilter {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("exactly one of ids or a non-empty filter is required", http.StatusBadRequest))
+		return
+	}
+
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	actor, _ := r.Context().Value("barcode").(float64)
+	update := domain.BulkStatusUpdate{
+		IDs:    req.IDs,
+		Status: domain.ComplaintStatus(req.Status),
+		Answer: req.Answer,
+		Actor:  int(actor),
+	}
+	if req.TemplateID != nil {
+		if req.Answer != "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("give either answer or template_id", http.StatusBadRequest))
+			return
+		}
+		template, err := h.TemplateService.GetByID(r.Context(), *req.TemplateID)
+		if errors.Is(err, storage.ErrTemplateNotFound) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get answer template", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+			return
+		}
+		update.AnswerFor = func(complaint domain.Complaint) (string, error) {
+			return h.TemplateService.Answer(template, complaint, req.Variables)
+		}
+	}
+	if hasFilter {
+		update.Filter = req.Filter.complaintFilter()
+	}
+
+	result, err := h.ComplaintService.BulkUpdateStatus(r.Context(), update)
+	if errors.Is(err, storage.ErrBulkTooLarge) {
+		render.Status(r, http.StatusRequestEntityTooLarge)
+		render.JSON(w, r, response.Error(
+			fmt.Sprintf("the filter matches more than %d complaints, narrow it down", serviceComplaint.BulkLimit),
+			http.StatusRequestEntityTooLarge,
+		))
+		return
+	}
+	if err != nil {
+		log.Error("bulk update failed", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("bulk status update",
+		slog.String("batch_id", result.BatchID.String()),
+		slog.Int("updated", result.Updated),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("not_found", result.NotFound),
+	)
+	render.JSON(w, r, response.Response{
+		Message:    "Bulk update finished",
+		StatusCode: http.StatusOK,
+		Data:       result,
+	})
+}
@@ -0,0 +1,120 @@
+package complaints
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const endorseRateKey = "rate:endorse:"
+
+// Endorse godoc
+// @Summary Back a public complaint
+// @Description Adds the student's "me too" to a complaint of a public category. Endorsing twice has no effect; a merged complaint's endorsement goes to its primary.
+// @Tags Complaints
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Complaint ID"
+// @Success 200 {object} domain.Endorsement "Endorsement state and count"
+// @Failure 400 {object} response.Response "Invalid ID or own complaint"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 403 {object} response.Response "Category is not public"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 429 {object} response.Response "Too many requests"
+// @Router /complaints/{id}/endorse [post]
+func (h Handler) Endorse(w http.ResponseWriter, r *http.Request) {
+	h.setEndorsement(w, r, true)
+}
+
+// Unendorse godoc
+// @Summary Withdraw an endorsement
+// @Tags Complaints
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Complaint ID"
+// @Success 200 {object} domain.Endorsement "Endorsement state and count"
+// @Failure 400 {object} response.Response "Invalid ID"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 403 {object} response.Response "Category is not public"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 429 {object} response.Response "Too many requests"
+// @Router /complaints/{id}/endorse [delete]
+func (h Handler) Unendorse(w http.ResponseWriter, r *http.Request) {
+	h.setEndorsement(w, r, false)
+}
+
+func (h Handler) setEndorsement(w http.ResponseWriter, r *http.Request, endorse bool) {
+	const op = "handlers.complaint.endorse.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if token == "" || err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing or invalid token", http.StatusUnauthorized))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid complaint id", http.StatusBadRequest))
+		return
+	}
+
+	if !h.allowEndorse(r.Context(), student.Barcode) {
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, response.Error("Too many endorsements, try again later", http.StatusTooManyRequests))
+		return
+	}
+
+	endorsement, err := h.ComplaintService.EndorseComplaint(r.Context(), id, student.Barcode, endorse)
+	switch {
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrNotPublic):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusForbidden))
+		return
+	case errors.Is(err, storage.ErrOwnComplaint):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+		return
+	case err != nil:
+		log.Error("failed to set endorsement", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       endorsement,
+	})
+}
+
+// allowEndorse counts the student's endorse requests in a fixed window, per replica while
+// Redis is unavailable.
+func (h Handler) allowEndorse(ctx context.Context, barcode int) bool {
+	window := config.ParseDuration(h.Log, "ENDORSE_RATE_WINDOW", h.Cfg.Endorsement.RateWindow, time.Hour)
+	count := h.Limits.Incr(ctx, endorseRateKey+strconv.Itoa(barcode), window)
+	return count <= int64(h.Cfg.Endorsement.RateLimit)
+}
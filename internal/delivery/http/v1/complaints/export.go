@@ -0,0 +1,107 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportParams reads the export parameters from the query string.
+func exportParams(query url.Values) (domain.ExportParams, error) {
+	params := domain.ExportParams{
+		Format:   query.Get("format"),
+		Timezone: query.Get("tz"),
+		Status:   query.Get("status"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	}
+	if raw := query.Get("columns"); raw != "" {
+		params.Columns = strings.Split(raw, ",")
+	}
+	if raw := query.Get("category_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return params, errors.New("invalid category_id")
+		}
+		params.CategoryID = &id
+	}
+	if raw := query.Get("pseudonymize"); raw != "" {
+		var err error
+		params.Pseudonymize, err = strconv.ParseBool(raw)
+		if err != nil {
+			return params, errors.New("pseudonymize must be true or false")
+		}
+	}
+	return params, nil
+}
+
+// Export godoc
+// @Summary Export complaints
+// @Description Streams the matching complaints as CSV or newline-delimited JSON, ordered by creation time.
+// @Tags Complaints
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Output format" Enums(csv, ndjson) default(csv)
+// @Param columns query string false "Comma separated columns: id, barcode, category_id, category, status, message, answer, created_at, updated_at"
+// @Param tz query string false "IANA time zone of the timestamps" default(UTC)
+// @Param pseudonymize query bool false "Replace barcodes with a stable keyed hash"
+// @Param status query string false "Only complaints with this status" Enums(pending, approved, rejected)
+// @Param category_id query string false "Only complaints of this category"
+// @Param from query string false "Created on or after this day (YYYY-MM-DD)"
+// @Param to query string false "Created on or before this day (YYYY-MM-DD)"
+// @Success 200 {string} string "Exported complaints"
+// @Failure 400 {object} response.Response "Invalid parameters"
+// @Failure 500 {object} response.Response "Streaming unsupported"
+// @Router /complaints/admin/export [get]
+func (h Handler) Export(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.export.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	params, err := exportParams(r.URL.Query())
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	filter, opts, err := h.Exporter.ParseParams(params)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	// A large export outlives the server write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Error("streaming unsupported", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("streaming unsupported", http.StatusInternalServerError))
+		return
+	}
+
+	filename := fmt.Sprintf("complaints-%s.%s", time.Now().In(opts.Location).Format("20060102-150405"), opts.Format)
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	count, err := h.Exporter.Export(r.Context(), w, filter, opts)
+	if err != nil {
+		// The status line is already sent, the client only sees a truncated file.
+		log.Error("export aborted", sl.Err(err), slog.Int("rows", count))
+		return
+	}
+	log.Info("complaints exported", slog.Int("rows", count), slog.String("format", string(opts.Format)))
+}
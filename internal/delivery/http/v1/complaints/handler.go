@@ -6,11 +6,19 @@ import (
 	serviceAdmin "complaint_server/internal/service/admin"
 	serviceCategory "complaint_server/internal/service/category"
 	serviceComplaint "complaint_server/internal/service/complaint"
+	serviceExport "complaint_server/internal/service/export"
+	serviceStats "complaint_server/internal/service/stats"
+	serviceStream "complaint_server/internal/service/stream"
+	serviceSuggest "complaint_server/internal/service/suggest"
+	serviceTemplate "complaint_server/internal/service/template"
+	"complaint_server/internal/shared/api/etag"
 	"complaint_server/internal/shared/api/response"
 	jwt2 "complaint_server/internal/shared/jwt"
 	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/shared/redact"
 	"complaint_server/internal/storage"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,17 +40,30 @@ type Handler struct {
 	AdminService     *serviceAdmin.AdminService
 	CategoryService  *serviceCategory.CategoryService
 	ComplaintService *serviceComplaint.ComplaintService
+	StreamHub        *serviceStream.Hub
+	StatsService     *serviceStats.StatsService
+	Exporter         *serviceExport.Exporter
+	SuggestService   *serviceSuggest.SuggestService
+	TemplateService  *serviceTemplate.TemplateService
 	Redis            *redis.Client
+	Limits           *storage.Counter
 	Cfg              *config.Config
 }
 
-func NewHandler(ctx context.Context, complaintsService *serviceComplaint.ComplaintService, adminService *serviceAdmin.AdminService, categoryService *serviceCategory.CategoryService, log *slog.Logger, redis *redis.Client, cfg *config.Config) *Handler {
+func NewHandler(ctx context.Context, complaintsService *serviceComplaint.ComplaintService, adminService *serviceAdmin.AdminService, categoryService *serviceCategory.CategoryService, streamHub *serviceStream.Hub, statsService *serviceStats.StatsService, exporter *serviceExport.Exporter, suggestService *serviceSuggest.SuggestService, templateService *serviceTemplate.TemplateService, log *slog.Logger, redis *redis.Client, cfg *config.Config) *Handler {
 	return &Handler{
 		AdminService:     adminService,
 		CategoryService:  categoryService,
 		ComplaintService: complaintsService,
+		StreamHub:        streamHub,
+		StatsService:     statsService,
+		Exporter:         exporter,
+		SuggestService:   suggestService,
+		TemplateService:  templateService,
 		Log:              log,
 		Redis:            redis,
+		Limits:           storage.NewCounter(redis),
+		Cfg:              cfg,
 	}
 }
 
@@ -53,6 +74,7 @@ func NewHandler(ctx context.Context, complaintsService *serviceComplaint.Complai
 // @Accept json
 // @Produce json
 // @Param id path int true "Complaint ID (unique identifier of the complaint)"
+// @Param sort query string false "endorsements puts the most endorsed complaints first" Enums(endorsements)
 // @Success 200 {object} domain.Complaint "Complaint details"
 // @Failure 400 {object} response.Response "Invalid request, incorrect ID format"
 // @Failure 404 {object} response.Response "Complaint with the given ID not found"
@@ -68,7 +90,7 @@ func (h Handler) GetAll(w http.ResponseWriter, r *http.Request) {
 		slog.String("url", r.URL.String()),
 	)
 
-	result, err := h.ComplaintService.GetAllComplaints(r.Context())
+	result, err := h.ComplaintService.GetAllComplaints(r.Context(), domain.ParseComplaintOrder(r.URL.Query().Get("sort")))
 	if errors.Is(err, storage.ErrComplaintNotFound) {
 		log.Error("complaint not found", sl.Err(err))
 		responseData, _ := json.Marshal(response.Response{
@@ -106,8 +128,9 @@ func (h Handler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param Request body Request true "Complaint details" // Request body with message, category_id, and barcode
-// @Success 200 {object} response.Response "Success response with complaint ID and answer"
+// @Success 200 {object} response.Response "Success response with complaint ID and answer. meta is a domain.DuplicateHint when similar complaints were reported recently"
 // @Failure 400 {object} response.Response "Invalid request, bad input or validation error"
+// @Failure 422 {object} response.Response "Message rejected by the content filter, e.g. it contains profanity"
 // @Failure 429 {object} response.Response "Limit of one complaint per hour exceeded"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /complaints [post]
@@ -126,12 +149,12 @@ func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
 	err := render.DecodeJSON(r.Body, &req)
 	if err != nil {
 		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, response.Response{
 			Message:    "failed to decode request",
 			StatusCode: http.StatusBadRequest,
 			Data:       nil,
 		})
-		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
@@ -140,12 +163,12 @@ func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
 		var validationErrors validator.ValidationErrors
 		errors.As(err, &validationErrors)
 		log.Error("failed to validate request", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, response.Response{
 			Message:    "validation error",
 			StatusCode: http.StatusBadRequest,
 			Data:       validationErrors,
 		})
-		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
@@ -153,42 +176,72 @@ func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
 	categoryID := req.CategoryID
 	barcode := req.Barcode
 
+	// Checked before saving so the new complaint doesn't match itself. The hint is best effort.
+	hint, err := h.ComplaintService.DuplicateHint(r.Context(), categoryID, message)
+	if err != nil {
+		log.Warn("failed to check for similar complaints", sl.Err(err))
+	}
+
 	complaintID, answer, err := h.ComplaintService.CreateComplaint(r.Context(), barcode, categoryID, message)
 
 	if errors.Is(err, storage.ErrLimitOneComplaintInOneHour) {
 		log.Error("failed to register complaint due to rate limit", sl.Err(err))
+		render.Status(r, http.StatusTooManyRequests)
 		render.JSON(w, r, response.Response{
 			Message:    "You can only submit one complaint per hour. Please try again later.",
 			StatusCode: http.StatusTooManyRequests,
 			Data:       nil,
 		})
-		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	var rejected *redact.RejectedError
+	if errors.As(err, &rejected) {
+		log.Info("complaint rejected by content filter", slog.String("detector", rejected.Detector))
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Response{
+			Message:    "Your message was rejected because it contains " + rejected.Detector + ". Please rephrase it.",
+			StatusCode: http.StatusUnprocessableEntity,
+		})
 		return
 	}
 
 	if err != nil {
 		log.Error("failed to register complaint", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, response.Response{
 			Message:    "failed to save complaint",
 			StatusCode: http.StatusInternalServerError,
 			Data:       nil,
 		})
-		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	// The suggestion is recorded in the background only to measure its accuracy, so a failure
+	// doesn't fail the request.
+	if err := h.SuggestService.Record(complaintID, message); err != nil {
+		log.Warn("failed to record category suggestion", sl.Err(err))
+	}
+
 	// Успешный ответ
+	var meta interface{}
+	if hint.PossiblyAlreadyReported {
+		meta = hint
+	}
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, response.Response{
 		Message:    answer,
 		StatusCode: http.StatusOK,
 		Data:       complaintID,
+		Meta:       meta,
 	})
 }
 
 // GetByComplaintId New GetComplaintById godoc
 // @Summary Get a complaint by ID
 // @Description Retrieve a complaint using its unique identifier. The UUID must be a string that corresponds to a valid complaint in the database.
+// @Description category_version holds the category title, description and answer the student saw when submitting.
+// @Description The ETag header carries the complaint version for If-Match on admin updates and deletes.
 // @Tags Complaints
 // @Accept json
 // @Produce json
@@ -238,6 +291,7 @@ func (h Handler) GetByComplaintId(w http.ResponseWriter, r *http.Request) {
 		StatusCode: http.StatusOK,
 		Data:       result,
 	})
+	etag.Set(w, result.Version)
 	w.Write(responseData)
 }
 
@@ -456,7 +510,7 @@ func (h Handler) DeleteByOwner(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	err = h.ComplaintService.DeleteComplaintById(ctx, complaintID)
+	err = h.ComplaintService.DeleteComplaintById(ctx, complaintID, 0)
 	if err != nil {
 		log.Error("Error on deleting complaint", "err", err)
 		render.JSON(w, r, response.Response{Message: "Error on deleting complaint", Data: nil, StatusCode: http.StatusInternalServerError})
@@ -474,14 +528,20 @@ func (h Handler) DeleteByOwner(w http.ResponseWriter, r *http.Request) {
 
 // Update New @Summary Update a complaint
 // @Description Updates an existing complaint based on the provided complaint ID and new data.
+// @Description With template_id, the answer is rendered from that answer template for the complaint as the update leaves it, including a changed category, with variables overriding its placeholders.
+// @Description If-Match must carry the ETag from GET /complaints/{id}; when the complaint changed in between, 412 returns its current state and ETag.
 // @Tags Complaints
 // @Accept json
 // @Produce json
 // @Param id path string true "Complaint ID"
+// @Param If-Match header string true "ETag of the complaint being updated"
 // @Param request body Request true "Complaint resolution details"
 // @Success 200 {object} Request "Complaint updated successfully"
 // @Failure 400 {object} response.Response "Invalid request"
-// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 404 {object} response.Response "Complaint, answer template or category not found"
+// @Failure 409 {object} response.Response "Complaint is merged into another one"
+// @Failure 412 {object} response.Response "Complaint changed, data holds the current version"
+// @Failure 428 {object} response.Response "If-Match header missing"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /admin/complaints/{id} [put]
 func (h Handler) Update(w http.ResponseWriter, r *http.Request) {
@@ -506,9 +566,15 @@ func (h Handler) Update(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Response{StatusCode: http.StatusBadRequest, Message: "invalid complaint ID"})
 		return
 	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
 
 	req := struct {
-		Complaint domain.Complaint `json:"data"`
+		Complaint  domain.Complaint  `json:"data"`
+		TemplateID *uuid.UUID        `json:"template_id"`
+		Variables  map[string]string `json:"variables"`
 	}{}
 
 	err = render.DecodeJSON(r.Body, &req)
@@ -520,8 +586,32 @@ func (h Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Info("request body decoded", slog.Any("request", req))
 
+	if req.TemplateID != nil {
+		answer, err := h.answerFromTemplate(ctx, id, req.Complaint.Category.ID, *req.TemplateID, req.Variables)
+		if h.templateError(w, r, log, err) {
+			return
+		}
+		req.Complaint.Answer = sql.NullString{String: answer, Valid: true}
+	}
+
 	log.Info("Complaints:", req.Complaint)
-	complaint, err := h.ComplaintService.UpdateComplaint(ctx, id, req.Complaint)
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	actor, _ := ctx.Value("barcode").(float64)
+	complaint, err := h.ComplaintService.UpdateComplaint(ctx, id, req.Complaint, int(actor), expectedVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		h.versionConflict(w, r, id)
+		return
+	}
+	if errors.Is(err, storage.ErrComplaintNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	}
+	if errors.Is(err, storage.ErrComplaintMerged) {
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	}
 	if err != nil {
 		log.Error("failed to update complaint", sl.Err(err))
 		render.JSON(w, r, response.Response{Message: "failed to update complaint", StatusCode: http.StatusInternalServerError})
@@ -529,17 +619,63 @@ func (h Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info("complaint updated", slog.Any("id", id))
+	etag.Set(w, complaint.Version)
 	render.JSON(w, r,
 		response.Response{Message: complaintID, StatusCode: http.StatusOK, Data: complaint},
 	)
 }
 
+// answerFromTemplate renders the template for the complaint as the update leaves it: with the
+// category the update sets, if it sets one.
+func (h Handler) answerFromTemplate(ctx context.Context, id uuid.UUID, categoryID uuid.UUID, templateID uuid.UUID, variables map[string]string) (string, error) {
+	complaint, err := h.ComplaintService.GetComplaintByUUID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if categoryID != uuid.Nil {
+		category, err := h.CategoryService.GetCategoryById(ctx, categoryID)
+		if err != nil {
+			return "", err
+		}
+		complaint.Category = category
+	}
+	return h.TemplateService.AnswerByID(ctx, templateID, complaint, variables)
+}
+
+// templateError writes the response for an error from answerFromTemplate and reports whether
+// the handler must stop.
+func (h Handler) templateError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrComplaintNotFound), errors.Is(err, storage.ErrTemplateNotFound),
+		errors.Is(err, storage.ErrCategoryNotFound):
+		log.Info("not found", sl.Err(err))
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusNotFound))
+	case errors.Is(err, serviceTemplate.ErrWrongCategory), errors.Is(err, serviceTemplate.ErrInvalidTemplate),
+		errors.Is(err, serviceTemplate.ErrRender):
+		log.Info("answer template rejected", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+	default:
+		log.Error("failed to render answer template", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+	}
+	return true
+}
+
 // DeleteByAdmin New @Summary Delete a complaint
 // @Description Delete a complaint by its ID. If the complaint is not found, an error is returned.
+// @Description If-Match must carry the ETag from GET /complaints/{id}; when the complaint changed in between, 412 returns its current state and ETag.
 // @Tags Complaints
 // @Param id path string true "Complaint ID"
+// @Param If-Match header string true "ETag of the complaint being deleted"
 // @Success 200 {object} response.Response "Complaint successfully deleted"
 // @Failure 400 {object} response.Response "Invalid request or complaint not found"
+// @Failure 412 {object} response.Response "Complaint changed, data holds the current version"
+// @Failure 428 {object} response.Response "If-Match header missing"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /admin/complaints/{id} [delete]
 func (h Handler) DeleteByAdmin(w http.ResponseWriter, r *http.Request) {
@@ -569,8 +705,16 @@ func (h Handler) DeleteByAdmin(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
 	ctx := r.Context()
-	err = h.ComplaintService.DeleteComplaintById(ctx, complaintUUID)
+	err = h.ComplaintService.DeleteComplaintById(ctx, complaintUUID, expectedVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		h.versionConflict(w, r, complaintUUID)
+		return
+	}
 	if errors.Is(err, storage.ErrComplaintNotFound) {
 		log.Error("complaint not found", sl.Err(err))
 		render.JSON(w, r, response.Response{
@@ -599,3 +743,20 @@ func (h Handler) DeleteByAdmin(w http.ResponseWriter, r *http.Request) {
 		Data:       nil,
 	})
 }
+
+// versionConflict answers a write whose If-Match missed with the complaint as it is now.
+func (h Handler) versionConflict(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	current, err := h.ComplaintService.GetComplaintByUUID(r.Context(), id)
+	if errors.Is(err, storage.ErrComplaintNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		h.Log.Error("failed to get complaint", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+	etag.PreconditionFailed(w, r, current.Version, current)
+}
@@ -0,0 +1,71 @@
+package complaints
+
+import (
+	serviceComplaint "complaint_server/internal/service/complaint"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// Original godoc
+// @Summary Read the original text of a complaint
+// @Description Returns the message as the student submitted it, before PII was masked. Only admins listed in CONTENT_AUDITORS may call it, and every read is recorded in complaint_audit.
+// @Tags Complaints
+// @Produce json
+// @Param id path string true "Complaint ID"
+// @Success 200 {object} domain.OriginalText "Original text"
+// @Failure 400 {object} response.Response "Invalid complaint ID"
+// @Failure 403 {object} response.Response "Not an auditor"
+// @Failure 404 {object} response.Response "Complaint not found or its text was not masked"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/{id}/original [get]
+func (h Handler) Original(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.original.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid complaint ID", http.StatusBadRequest))
+		return
+	}
+
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	actor, _ := r.Context().Value("barcode").(float64)
+	original, err := h.ComplaintService.ReadOriginal(r.Context(), id, int(actor))
+	switch {
+	case errors.Is(err, serviceComplaint.ErrNotAuditor):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusForbidden))
+		return
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrNoOriginal):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusNotFound))
+		return
+	case err != nil:
+		log.Error("failed to read original text", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("original text read", slog.Any("id", id), slog.Int("actor", int(actor)))
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       original,
+	})
+}
@@ -0,0 +1,128 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/etag"
+	"complaint_server/internal/shared/api/mergepatch"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// patchableFields are the complaint fields an admin may change with a merge patch.
+var patchableFields = []string{"status", "answer", "category_id"}
+
+// PatchRequest is a JSON merge patch of a complaint. A null answer clears it.
+type PatchRequest struct {
+	Status     *string    `json:"status" validate:"omitempty,oneof=pending approved rejected" example:"approved"`
+	Answer     *string    `json:"answer" validate:"omitempty,max=8000" example:"The heating was repaired"`
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+// Patch godoc
+// @Summary Partially update a complaint
+// @Description Changes only the given fields: status, answer and category_id, as a JSON merge patch. Status changes follow the lifecycle (pending to approved or rejected and back) and every change is audited.
+// @Description If-Match must carry the ETag from GET /complaints/{id}; when the complaint changed in between, 412 returns its current state and ETag.
+// @Tags Complaints
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Complaint ID"
+// @Param If-Match header string true "ETag of the complaint being updated"
+// @Param request body PatchRequest true "Fields to change"
+// @Success 200 {object} domain.Complaint "Updated complaint"
+// @Failure 400 {object} response.Response "Invalid patch, field or category"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 409 {object} response.Response "Status change not allowed or complaint is merged"
+// @Failure 412 {object} response.Response "Complaint changed, data holds the current version"
+// @Failure 415 {object} response.Response "Body is not application/merge-patch+json"
+// @Failure 428 {object} response.Response "If-Match header missing"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/{id} [patch]
+func (h Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.patch.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid complaint id", http.StatusBadRequest))
+		return
+	}
+	expectedVersion, ok := etag.Require(w, r)
+	if !ok {
+		return
+	}
+
+	var req PatchRequest
+	present, err := mergepatch.Decode(r, &req, patchableFields, "answer")
+	if err != nil {
+		mergepatch.WriteError(w, r, err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+
+	patch := domain.ComplaintPatch{Answer: req.Answer, CategoryID: req.CategoryID}
+	if req.Status != nil {
+		status := domain.ComplaintStatus(*req.Status)
+		patch.Status = &status
+	}
+	if present["answer"] && req.Answer == nil {
+		cleared := ""
+		patch.Answer = &cleared
+	}
+
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	actor, _ := r.Context().Value("barcode").(float64)
+	complaint, err := h.ComplaintService.PatchComplaint(r.Context(), id, patch, int(actor), expectedVersion)
+	switch {
+	case errors.Is(err, storage.ErrVersionMismatch):
+		h.versionConflict(w, r, id)
+		return
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrCategoryNotFound):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Category not found", http.StatusBadRequest))
+		return
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, storage.ErrComplaintMerged):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	case err != nil:
+		log.Error("failed to patch complaint", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("complaint patched", slog.Any("id", id), slog.Any("fields", present))
+	etag.Set(w, complaint.Version)
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Complaint updated",
+		Data:       complaint,
+	})
+}
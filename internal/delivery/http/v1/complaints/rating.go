@@ -0,0 +1,120 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type RatingRequest struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5" example:"4"`
+	Comment string `json:"comment" validate:"max=1000" example:"Fixed, but it took a week"`
+	// Resolved false means the problem is still there and reopens the complaint. Defaults to true.
+	Resolved *bool `json:"resolved" example:"true"`
+}
+
+// Rate godoc
+// @Summary Rate how a complaint was handled
+// @Description The owner rates a resolved complaint from 1 to 5, once, within RATING_WINDOW after resolution. With "resolved": false the complaint goes back to pending.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Complaint ID"
+// @Param request body RatingRequest true "Rating"
+// @Success 201 {object} domain.Rating "Rating saved"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 403 {object} response.Response "Not the owner of the complaint"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 409 {object} response.Response "Already rated, not resolved or rating window closed"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/{id}/rating [post]
+func (h Handler) Rate(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.rating.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if token == "" || err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing or invalid token", http.StatusUnauthorized))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid complaint id", http.StatusBadRequest))
+		return
+	}
+
+	var req RatingRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+
+	rating := domain.Rating{
+		ComplaintID: id,
+		Rating:      req.Rating,
+		Comment:     strings.TrimSpace(req.Comment),
+		Resolved:    req.Resolved == nil || *req.Resolved,
+	}
+	rating, err = h.ComplaintService.RateComplaint(r.Context(), rating, student.Barcode)
+	switch {
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrNotComplaintOwner):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusForbidden))
+		return
+	case errors.Is(err, storage.ErrNotResolved),
+		errors.Is(err, storage.ErrRatingWindowClosed),
+		errors.Is(err, storage.ErrAlreadyRated):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	case err != nil:
+		log.Error("failed to rate complaint", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("complaint rated", slog.Any("id", id), slog.Int("rating", rating.Rating), slog.Bool("reopened", rating.Reopened))
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusCreated,
+		Data:       rating,
+	})
+}
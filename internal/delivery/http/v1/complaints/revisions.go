@@ -0,0 +1,199 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/shared/redact"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// EditRequest changes the message, the category or both; omitted fields are kept.
+type EditRequest struct {
+	Message    *string    `json:"message" validate:"omitempty,min=1" example:"Too noisy in the dorm after 11pm"`
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+// Edit godoc
+// @Summary Edit own pending complaint
+// @Description The owner fixes the message or category of a complaint while it is pending and no admin has answered it. Each edit is kept as a revision and the complaint gets edited_at.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Complaint ID"
+// @Param request body EditRequest true "Fields to change"
+// @Success 200 {object} domain.Complaint "Edited complaint"
+// @Failure 400 {object} response.Response "Invalid request or unknown category"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 403 {object} response.Response "Not the owner of the complaint"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 409 {object} response.Response "Complaint is already being handled"
+// @Failure 422 {object} response.Response "Message rejected by the content filter"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/{id} [patch]
+func (h Handler) Edit(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.edit.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if token == "" || err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing or invalid token", http.StatusUnauthorized))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid complaint id", http.StatusBadRequest))
+		return
+	}
+
+	var req EditRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if req.Message != nil {
+		message := strings.TrimSpace(*req.Message)
+		req.Message = &message
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+	if req.Message == nil && req.CategoryID == nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Nothing to change, set message or category_id", http.StatusBadRequest))
+		return
+	}
+
+	edit := domain.ComplaintEdit{Message: req.Message, CategoryID: req.CategoryID}
+	complaint, err := h.ComplaintService.EditComplaint(r.Context(), id, student.Barcode, edit)
+	var rejected *redact.RejectedError
+	switch {
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrNotComplaintOwner):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusForbidden))
+		return
+	case errors.Is(err, storage.ErrComplaintLocked):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	case errors.Is(err, storage.ErrCategoryNotFound):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Category not found", http.StatusBadRequest))
+		return
+	case errors.As(err, &rejected):
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error("Your message was rejected because it contains "+rejected.Detector+". Please rephrase it.", http.StatusUnprocessableEntity))
+		return
+	case err != nil:
+		log.Error("failed to edit complaint", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("complaint edited", slog.Any("id", id))
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Complaint updated",
+		Data:       complaint,
+	})
+}
+
+// Revisions godoc
+// @Summary Edit history of a complaint
+// @Description Revisions oldest first; revision 1 is the complaint as submitted. Each later revision lists its word changes against the previous one. Available to the owner and admins.
+// @Tags Complaints
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Complaint ID"
+// @Success 200 {array} domain.ComplaintRevision "Revisions"
+// @Failure 400 {object} response.Response "Invalid ID"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 403 {object} response.Response "Not the owner of the complaint"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/{id}/revisions [get]
+func (h Handler) Revisions(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.revisions.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if token == "" || err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing or invalid token", http.StatusUnauthorized))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Invalid complaint id", http.StatusBadRequest))
+		return
+	}
+
+	isAdmin, err := h.AdminService.IsAdmin(r.Context(), student.Barcode)
+	if err != nil {
+		log.Error("failed to check admin", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	revisions, err := h.ComplaintService.GetRevisions(r.Context(), id, student.Barcode, isAdmin)
+	switch {
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrNotComplaintOwner):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusForbidden))
+		return
+	case err != nil:
+		log.Error("failed to get revisions", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       revisions,
+	})
+}
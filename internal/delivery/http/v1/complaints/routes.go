@@ -9,12 +9,32 @@ func RegisterRoutes(r chi.Router, h *Handler) {
 	r.Get("/", h.GetAll)
 
 	r.Post("/", h.Create)
+	r.Post("/suggest-category", h.SuggestCategory)
 	r.Get("/{id}", h.GetByComplaintId)
+	r.Patch("/{id}", h.Edit)
+	r.Get("/{id}/revisions", h.Revisions)
 	r.Get("/can-submit", h.CanSubmit)
 	r.Get("/by-token", h.GetComplaintsByToken)
+	r.Get("/ws", h.Subscribe)
 	r.Delete("/{id}", h.DeleteByOwner)
+	r.Post("/{id}/endorse", h.Endorse)
+	r.Delete("/{id}/endorse", h.Unendorse)
+	r.Post("/{id}/rating", h.Rate)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/stream", h.Stream)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/stats", h.Stats)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/export", h.Export)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/bulk", h.Bulk)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/{id}/similar", h.Similar)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/{id}/merge", h.Merge)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Put("/admin/{id}/board", h.SetBoardVisibility)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/{id}/original", h.Original)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/suggest/retrain", h.RetrainSuggest)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/suggest/accuracy", h.SuggestAccuracy)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Get("/admin/suggest/keywords", h.KeywordRules)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Put("/admin/suggest/keywords/{category_id}", h.SetKeywords)
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Post("/admin/", h.Create)
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Put("/admin/{id}", h.Update)
+	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Patch("/admin/{id}", h.Patch)
 	r.With(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService)).Delete("/admin/{id}", h.DeleteByAdmin)
 
 }
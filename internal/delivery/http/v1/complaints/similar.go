@@ -0,0 +1,138 @@
+package complaints
+
+import (
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// Similar godoc
+// @Summary Find similar complaints
+// @Description Lists complaints of the same category created within SIMILARITY_WINDOW whose message is similar (pg_trgm) or, with SIMILARITY_FULL_TEXT, contains all words of the other.
+// @Tags Complaints
+// @Produce json
+// @Param id path string true "Complaint ID"
+// @Success 200 {array} domain.SimilarComplaint "Similar complaints, most similar first"
+// @Failure 400 {object} response.Response "Invalid complaint ID"
+// @Failure 404 {object} response.Response "Complaint not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/{id}/similar [get]
+func (h Handler) Similar(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.similar.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid complaint ID", http.StatusBadRequest))
+		return
+	}
+
+	similar, err := h.ComplaintService.FindSimilar(r.Context(), id)
+	if errors.Is(err, storage.ErrComplaintNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		log.Error("failed to find similar complaints", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       similar,
+	})
+}
+
+type MergeRequest struct {
+	DuplicateIDs []uuid.UUID `json:"duplicate_ids" validate:"required,min=1,max=500"`
+}
+
+// Merge godoc
+// @Summary Merge duplicate complaints
+// @Description Merges the duplicates into the complaint from the path. They take its status and answer now and whenever it changes later.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Param id path string true "Primary complaint ID"
+// @Param request body MergeRequest true "Duplicates to merge"
+// @Success 200 {object} domain.BulkResult "Per-complaint results"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Primary complaint not found"
+// @Failure 409 {object} response.Response "Primary complaint is itself merged"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/{id}/merge [post]
+func (h Handler) Merge(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.merge.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	primaryID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid complaint ID", http.StatusBadRequest))
+		return
+	}
+
+	var req MergeRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return
+		}
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return
+	}
+
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	actor, _ := r.Context().Value("barcode").(float64)
+
+	result, err := h.ComplaintService.Merge(r.Context(), primaryID, req.DuplicateIDs, int(actor))
+	switch {
+	case errors.Is(err, storage.ErrComplaintNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Complaint not found", http.StatusNotFound))
+		return
+	case errors.Is(err, storage.ErrPrimaryIsMerged):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusConflict))
+		return
+	case err != nil:
+		log.Error("failed to merge complaints", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("complaints merged", slog.Any("primary_id", primaryID), slog.Int("merged", result.Updated))
+	render.JSON(w, r, response.Response{
+		Message:    "Complaints merged",
+		StatusCode: http.StatusOK,
+		Data:       result,
+	})
+}
@@ -0,0 +1,102 @@
+package complaints
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const statsDateLayout = "2006-01-02"
+
+// Stats godoc
+// @Summary Complaint statistics
+// @Description Counts by status and category, a time series of new complaints, time-to-first-answer and time-to-resolution percentiles, and approval/rejection ratios.
+// @Description Numbers come from materialized views refreshed every STATS_REFRESH_INTERVAL, so they may lag slightly behind.
+// @Description CSAT (satisfaction ratings given by owners after resolution) is read live and filtered by the day the rating was given; per category and per admin lists start with the lowest average.
+// @Tags Complaints
+// @Produce json
+// @Param from query string false "First day, inclusive (YYYY-MM-DD)"
+// @Param to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Param category_id query string false "Only complaints of this category"
+// @Param interval query string false "Series bucket size" Enums(day, week, month) default(day)
+// @Success 200 {object} domain.ComplaintStats "Statistics"
+// @Failure 400 {object} response.Response "Invalid filter"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/stats [get]
+func (h Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.stats.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	query := r.URL.Query()
+	filter := domain.StatsFilter{Interval: domain.StatsInterval(query.Get("interval"))}
+
+	switch filter.Interval {
+	case "":
+		filter.Interval = domain.IntervalDay
+	case domain.IntervalDay, domain.IntervalWeek, domain.IntervalMonth:
+	default:
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("interval must be day, week or month", http.StatusBadRequest))
+		return
+	}
+
+	if raw := query.Get("from"); raw != "" {
+		from, err := time.Parse(statsDateLayout, raw)
+		if err != nil {
+			log.Error("invalid from", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("from must be YYYY-MM-DD", http.StatusBadRequest))
+			return
+		}
+		filter.From = &from
+	}
+	if raw := query.Get("to"); raw != "" {
+		to, err := time.Parse(statsDateLayout, raw)
+		if err != nil {
+			log.Error("invalid to", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("to must be YYYY-MM-DD", http.StatusBadRequest))
+			return
+		}
+		// The range includes the whole last day.
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("from must not be after to", http.StatusBadRequest))
+		return
+	}
+
+	if raw := query.Get("category_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			log.Error("invalid category id", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid category_id", http.StatusBadRequest))
+			return
+		}
+		filter.CategoryID = &id
+	}
+
+	stats, err := h.StatsService.GetStats(r.Context(), filter)
+	if err != nil {
+		log.Error("failed to get stats", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       stats,
+	})
+}
@@ -0,0 +1,107 @@
+package complaints
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	serviceStream "complaint_server/internal/service/stream"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Stream godoc
+// @Summary Live feed of complaint events
+// @Description Server-Sent Events stream of complaint.created, complaint.updated, complaint.status_changed and complaint.deleted events.
+// @Description Reconnecting clients send Last-Event-ID to receive the events they missed, as long as they are still in the replay buffer.
+// @Tags Complaints
+// @Produce text/event-stream
+// @Param category_id query []string false "Only events of these categories" collectionFormat(multi)
+// @Param Last-Event-ID header string false "ID of the last received event"
+// @Success 200 {object} domain.Event "Stream of events"
+// @Failure 400 {object} response.Response "Invalid category id"
+// @Failure 500 {object} response.Response "Streaming unsupported"
+// @Router /complaints/admin/stream [get]
+func (h Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.stream.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	var filter serviceStream.Filter
+	for _, raw := range r.URL.Query()["category_id"] {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			log.Error("invalid category id", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid category_id", http.StatusBadRequest))
+			return
+		}
+		filter.CategoryIDs = append(filter.CategoryIDs, id)
+	}
+
+	rc := http.NewResponseController(w)
+	// The server write timeout would otherwise cut the stream.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Error("streaming unsupported", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("streaming unsupported", http.StatusInternalServerError))
+		return
+	}
+
+	events, missed, cancel := h.StreamHub.Subscribe(filter, r.Header.Get("Last-Event-ID"))
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	_ = rc.Flush()
+
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(config.ParseDuration(log, "STREAM_HEARTBEAT", h.Cfg.Stream.Heartbeat, 15*time.Second))
+	defer heartbeat.Stop()
+
+	log.Info("stream opened", slog.Int("replayed", len(missed)))
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Info("stream closed")
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
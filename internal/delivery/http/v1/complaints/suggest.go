@@ -0,0 +1,259 @@
+package complaints
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const suggestRateKey = "rate:suggest:"
+
+type SuggestRequest struct {
+	Message string `json:"message" validate:"required" example:"The heating in block C doesn't work"`
+}
+
+type KeywordsRequest struct {
+	Keywords []string `json:"keywords" example:"dorm,roommate"`
+}
+
+// SuggestCategory godoc
+// @Summary Suggest categories for a draft complaint
+// @Description Ranks categories by how well they fit the message, combining admin keyword rules with a classifier trained on past complaints. May return an empty list. Limited to SUGGEST_RATE_LIMIT requests per student per SUGGEST_RATE_WINDOW.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SuggestRequest true "Draft message"
+// @Success 200 {array} domain.CategorySuggestion "Suggestions, best first"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 429 {object} response.Response "Too many requests"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/suggest-category [post]
+func (h Handler) SuggestCategory(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.suggest_category.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if token == "" || err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing or invalid token", http.StatusUnauthorized))
+		return
+	}
+	window := config.ParseDuration(h.Log, "SUGGEST_RATE_WINDOW", h.Cfg.Suggest.RateWindow, time.Hour)
+	if h.Limits.Incr(r.Context(), suggestRateKey+strconv.Itoa(student.Barcode), window) > int64(h.Cfg.Suggest.RateLimit) {
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, response.Error("Too many suggestion requests, try again later", http.StatusTooManyRequests))
+		return
+	}
+
+	var req SuggestRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("message is required", http.StatusBadRequest))
+		return
+	}
+
+	suggestions, err := h.SuggestService.Suggest(r.Context(), req.Message)
+	if err != nil {
+		log.Error("failed to suggest categories", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       suggestions,
+	})
+}
+
+// RetrainSuggest godoc
+// @Summary Retrain the category classifier
+// @Description Trains the classifier from complaints of the last SUGGEST_TRAIN_WINDOW right away instead of waiting for SUGGEST_RETRAIN_INTERVAL. Only the replica serving the request is retrained.
+// @Tags Complaints
+// @Produce json
+// @Success 200 {object} response.Response "Model summary"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/suggest/retrain [post]
+func (h Handler) RetrainSuggest(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.retrain_suggest.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	model, err := h.SuggestService.Retrain(r.Context())
+	if err != nil {
+		log.Error("failed to retrain category model", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("category model retrained", slog.Int("samples", model.Samples))
+	render.JSON(w, r, response.Response{
+		Message:    "Model retrained",
+		StatusCode: http.StatusOK,
+		Data: map[string]interface{}{
+			"trained_at": model.TrainedAt,
+			"samples":    model.Samples,
+			"vocabulary": model.Vocabulary,
+			"categories": len(model.Classes),
+		},
+	})
+}
+
+// SuggestAccuracy godoc
+// @Summary Accuracy of category suggestions
+// @Description Compares the category suggested when each complaint was submitted with its current category, including admin corrections.
+// @Tags Complaints
+// @Produce json
+// @Param from query string false "First day, inclusive (YYYY-MM-DD)"
+// @Param to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} domain.SuggestionAccuracy "Accuracy"
+// @Failure 400 {object} response.Response "Invalid dates"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/suggest/accuracy [get]
+func (h Handler) SuggestAccuracy(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.suggest_accuracy.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	var from, to *time.Time
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		t, err := time.Parse(statsDateLayout, raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("from must be YYYY-MM-DD", http.StatusBadRequest))
+			return
+		}
+		from = &t
+	}
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		t, err := time.Parse(statsDateLayout, raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("to must be YYYY-MM-DD", http.StatusBadRequest))
+			return
+		}
+		// The range includes the whole last day.
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+
+	acc, err := h.SuggestService.Accuracy(r.Context(), from, to)
+	if err != nil {
+		log.Error("failed to get suggestion accuracy", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       acc,
+	})
+}
+
+// KeywordRules godoc
+// @Summary List keyword rules
+// @Description Keywords that point to a category whenever a message contains them.
+// @Tags Complaints
+// @Produce json
+// @Success 200 {array} domain.KeywordRule "Rules"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/suggest/keywords [get]
+func (h Handler) KeywordRules(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.keyword_rules.New"
+	log := h.Log.With(slog.String("op", op))
+
+	rules, err := h.SuggestService.KeywordRules(r.Context())
+	if err != nil {
+		log.Error("failed to get keyword rules", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       rules,
+	})
+}
+
+// SetKeywords godoc
+// @Summary Replace the keyword rules of a category
+// @Description An empty list removes the rules. Keywords are matched anywhere in the message, ignoring case.
+// @Tags Complaints
+// @Accept json
+// @Produce json
+// @Param category_id path string true "Category ID"
+// @Param request body KeywordsRequest true "Keywords"
+// @Success 200 {object} response.Response "Rules replaced"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Category not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /complaints/admin/suggest/keywords/{category_id} [put]
+func (h Handler) SetKeywords(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.set_keywords.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	categoryID, err := uuid.Parse(chi.URLParam(r, "category_id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid category ID", http.StatusBadRequest))
+		return
+	}
+	var req KeywordsRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+
+	err = h.SuggestService.SetKeywords(r.Context(), categoryID, req.Keywords)
+	if errors.Is(err, storage.ErrCategoryNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Category not found", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		log.Error("failed to set keywords", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		Message:    "Keywords updated",
+		StatusCode: http.StatusOK,
+	})
+}
@@ -0,0 +1,140 @@
+package complaints
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	serviceStream "complaint_server/internal/service/stream"
+	"complaint_server/internal/shared/api/response"
+	jwt2 "complaint_server/internal/shared/jwt"
+	"complaint_server/internal/shared/logger/sl"
+	"errors"
+	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const wsWriteWait = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// CORS is open for the whole API, the student token is what protects this endpoint.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// studentEvents are the events a student gets about their own complaints.
+var studentEvents = []domain.EventType{
+	domain.EventComplaintStatusChanged,
+	domain.EventComplaintUpdated,
+}
+
+// Subscribe godoc
+// @Summary Real-time updates of the student's complaints
+// @Description Upgrades to a WebSocket that pushes status changes and answer edits of the complaints owned by the token's barcode.
+// @Description Browsers can't set headers on WebSocket requests, so the token may be passed as a query parameter instead.
+// @Tags Complaints
+// @Param token query string false "Student JWT, if no Authorization header is sent"
+// @Success 101 {object} domain.Event "Switching protocols, then a stream of events as JSON text messages"
+// @Failure 401 {object} response.Response "Missing or invalid token"
+// @Failure 429 {object} response.Response "Too many connections for this student"
+// @Router /complaints/ws [get]
+func (h Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.complaint.subscribe.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("remote_addr", r.RemoteAddr),
+	)
+
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	if token == "" {
+		log.Error("missing token")
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Missing token", http.StatusUnauthorized))
+		return
+	}
+	student, err := jwt2.EncodeJWT(h.Cfg.JwtSecret, token)
+	if err != nil {
+		log.Error("invalid token", sl.Err(err))
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("Invalid token", http.StatusUnauthorized))
+		return
+	}
+	log = log.With(slog.Int("barcode", student.Barcode))
+
+	pingInterval := config.ParseDuration(log, "WS_PING_INTERVAL", h.Cfg.Stream.WSPingInterval, 30*time.Second)
+	pongWait := pingInterval * 2
+
+	release, err := h.StreamHub.AcquireConnection(r.Context(), student.Barcode, h.Cfg.Stream.WSMaxConnections, pongWait)
+	if errors.Is(err, serviceStream.ErrTooManyConnections) {
+		log.Warn("too many connections")
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusTooManyRequests))
+		return
+	}
+	if err != nil {
+		log.Error("failed to register connection", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+	defer release()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response.
+		log.Error("failed to upgrade connection", sl.Err(err))
+		return
+	}
+	defer conn.Close()
+
+	events, _, cancel := h.StreamHub.Subscribe(serviceStream.Filter{
+		Barcode:    student.Barcode,
+		EventTypes: studentEvents,
+	}, "")
+	defer cancel()
+
+	// The reader only handles control frames; it stops when the client goes away or stops answering pings.
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		h.StreamHub.TouchConnection(r.Context(), student.Barcode, pongWait)
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	log.Info("websocket opened")
+	for {
+		select {
+		case <-closed:
+			log.Info("websocket closed")
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case event := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				log.Error("failed to write event", sl.Err(err))
+				return
+			}
+		}
+	}
+}
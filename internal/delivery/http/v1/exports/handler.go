@@ -0,0 +1,187 @@
+package exports
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	serviceAdmin "complaint_server/internal/service/admin"
+	serviceExport "complaint_server/internal/service/export"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	Log          *slog.Logger
+	AdminService *serviceAdmin.AdminService
+	JobService   *serviceExport.JobService
+	Cfg          *config.Config
+}
+
+func NewHandler(ctx context.Context, jobService *serviceExport.JobService, adminService *serviceAdmin.AdminService, log *slog.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		AdminService: adminService,
+		JobService:   jobService,
+		Log:          log,
+		Cfg:          cfg,
+	}
+}
+
+// Create @Summary Start an export job
+// @Description Queues a background export of the matching complaints. Poll GET /exports/{id} for progress and the download link.
+// @Tags Exports
+// @Accept json
+// @Produce json
+// @Param request body domain.ExportParams true "Filters and output options"
+// @Success 202 {object} domain.ExportJob "Job queued"
+// @Failure 400 {object} response.Response "Invalid parameters"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /exports [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.exports.create.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var params domain.ExportParams
+	if err := render.DecodeJSON(r.Body, &params); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+
+	// AdminOnlyMiddleware stores the barcode claim as it came in the token.
+	barcode, _ := r.Context().Value("barcode").(float64)
+
+	job, err := h.JobService.Create(r.Context(), params, int(barcode))
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("export job queued", slog.Any("id", job.ID))
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, response.Response{
+		Message:    "Export job queued",
+		StatusCode: http.StatusAccepted,
+		Data:       job,
+	})
+}
+
+// GetById @Summary Get an export job
+// @Description Returns the job status and progress. Finished jobs include a signed download link that expires after EXPORT_LINK_TTL.
+// @Tags Exports
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} domain.ExportJob "Job details"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Job not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /exports/{id} [get]
+func (h *Handler) GetById(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.exports.get_by_id.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log)
+	if !ok {
+		return
+	}
+
+	job, err := h.JobService.Get(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		Message:    "Export job fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       job,
+	})
+}
+
+// Download @Summary Download an export
+// @Description Serves the gzip file of a finished job. The link comes from GET /exports/{id}.
+// @Tags Exports
+// @Produce application/gzip
+// @Param id path string true "Job ID"
+// @Param expires query int true "Link expiry, Unix seconds"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file "Export file"
+// @Failure 403 {object} response.Response "Invalid or expired link"
+// @Failure 404 {object} response.Response "Job not found"
+// @Failure 409 {object} response.Response "Export not finished"
+// @Router /exports/{id}/download [get]
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.exports.download.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, ok := h.parseID(w, r, log)
+	if !ok {
+		return
+	}
+
+	file, job, err := h.JobService.Open(r.Context(), id, r.URL.Query().Get("expires"), r.URL.Query().Get("signature"))
+	if h.handleError(w, r, log, err) {
+		return
+	}
+	defer file.Close()
+
+	// Large files outlive the server write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+serviceExport.Filename(job)+`"`)
+	http.ServeContent(w, r, "", *job.FinishedAt, file)
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id", http.StatusBadRequest))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError writes the response for err and reports whether the handler must stop.
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	status := http.StatusInternalServerError
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, serviceExport.ErrInvalidParams):
+		status = http.StatusBadRequest
+	case errors.Is(err, storage.ErrExportJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, serviceExport.ErrInvalidSignature), errors.Is(err, serviceExport.ErrLinkExpired):
+		status = http.StatusForbidden
+	case errors.Is(err, serviceExport.ErrNotReady):
+		status = http.StatusConflict
+	default:
+		log.Error("internal error", sl.Err(err))
+		render.Status(r, status)
+		render.JSON(w, r, response.Error("internal error", status))
+		return true
+	}
+	log.Error("request failed", sl.Err(err))
+	render.Status(r, status)
+	render.JSON(w, r, response.Error(err.Error(), status))
+	return true
+}
@@ -0,0 +1,17 @@
+package exports
+
+import (
+	"complaint_server/internal/delivery/http/middleware/admin"
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	// The download link is signed, so it works without a token, e.g. from a browser tab.
+	r.Get("/{id}/download", h.Download)
+
+	r.Group(func(r chi.Router) {
+		r.Use(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService))
+		r.Post("/", h.Create)
+		r.Get("/{id}", h.GetById)
+	})
+}
@@ -2,14 +2,29 @@ package httpserver
 
 import (
 	"complaint_server/internal/config"
+	"complaint_server/internal/delivery/http/middleware/cache"
+	"complaint_server/internal/delivery/http/middleware/idempotency"
 	mwLogger "complaint_server/internal/delivery/http/middleware/logger"
 	"complaint_server/internal/delivery/http/v1/categories"
 	"complaint_server/internal/delivery/http/v1/complaints"
+	"complaint_server/internal/delivery/http/v1/exports"
+	"complaint_server/internal/delivery/http/v1/health"
+	"complaint_server/internal/delivery/http/v1/public"
+	"complaint_server/internal/delivery/http/v1/templates"
+	"complaint_server/internal/delivery/http/v1/webhooks"
 
 	serviceAdmin "complaint_server/internal/service/admin"
 	serviceCategory "complaint_server/internal/service/category"
 	serviceComplaint "complaint_server/internal/service/complaint"
+	serviceExport "complaint_server/internal/service/export"
+	serviceStats "complaint_server/internal/service/stats"
+	serviceStream "complaint_server/internal/service/stream"
+	serviceSuggest "complaint_server/internal/service/suggest"
+	serviceTemplate "complaint_server/internal/service/template"
+	serviceWebhook "complaint_server/internal/service/webhook"
+	"complaint_server/internal/storage"
 	"context"
+	"expvar"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -27,27 +42,64 @@ func RegisterRoutes(
 	router chi.Router,
 	log *slog.Logger,
 	client *redis.Client,
+	breaker *storage.Breaker,
+	db health.Pinger,
 	complaintsService *serviceComplaint.ComplaintService,
 	categoriesService *serviceCategory.CategoryService,
 	adminService *serviceAdmin.AdminService,
+	webhookService *serviceWebhook.WebhookService,
+	streamHub *serviceStream.Hub,
+	statsService *serviceStats.StatsService,
+	exporter *serviceExport.Exporter,
+	exportJobService *serviceExport.JobService,
+	suggestService *serviceSuggest.SuggestService,
+	templateService *serviceTemplate.TemplateService,
 ) {
 
 	router.Use(middleware.RequestID, middleware.RealIP, middleware.Logger,
 		middleware.Recoverer, httprate.Limit(50, requestLimitTimeout))
 	router.Use(mwLogger.New(log))
+	router.Use(idempotency.New(client, cfg, log))
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", idempotency.Header},
+		ExposedHeaders:   []string{"ETag", "Age", cache.StatusHeader, idempotency.ReplayedHeader},
 		AllowCredentials: true,
 	}))
 
+	if cfg.HTTPServer.User != "" {
+		router.With(middleware.BasicAuth("metrics", map[string]string{
+			cfg.HTTPServer.User: cfg.HTTPServer.Password,
+		})).Handle("/debug/vars", expvar.Handler())
+	}
+
+	router.Route("/health", func(r chi.Router) {
+		healthHandler := health.NewHandler(ctx, db, breaker, log)
+		health.RegisterRoutes(r, healthHandler)
+	})
 	router.Route("/categories", func(r chi.Router) {
 		categoryHandler := categories.NewHandler(ctx, complaintsService, adminService, categoriesService, log, client, cfg)
 		categories.RegisterRoutes(r, categoryHandler)
 	})
 	router.Route("/complaints", func(r chi.Router) {
-		complaintHandler := complaints.NewHandler(ctx, complaintsService, adminService, categoriesService, log, client, cfg)
+		complaintHandler := complaints.NewHandler(ctx, complaintsService, adminService, categoriesService, streamHub, statsService, exporter, suggestService, templateService, log, client, cfg)
 		complaints.RegisterRoutes(r, complaintHandler)
 	})
+	router.Route("/public", func(r chi.Router) {
+		publicHandler := public.NewHandler(ctx, complaintsService, log)
+		public.RegisterRoutes(r, publicHandler)
+	})
+	router.Route("/exports", func(r chi.Router) {
+		exportHandler := exports.NewHandler(ctx, exportJobService, adminService, log, cfg)
+		exports.RegisterRoutes(r, exportHandler)
+	})
+	router.Route("/templates", func(r chi.Router) {
+		templateHandler := templates.NewHandler(ctx, templateService, adminService, log, cfg)
+		templates.RegisterRoutes(r, templateHandler)
+	})
+	router.Route("/webhooks", func(r chi.Router) {
+		webhookHandler := webhooks.NewHandler(ctx, webhookService, adminService, log, cfg)
+		webhooks.RegisterRoutes(r, webhookHandler)
+	})
 }
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const pingTimeout = 2 * time.Second
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Redis is down, the app runs on its in-memory fallbacks
+	StatusDown     = "down"     // Postgres is down
+)
+
+// Pinger is a dependency the service can't work without.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Handler struct {
+	Log      *slog.Logger
+	Postgres Pinger
+	Redis    *storage.Breaker
+}
+
+func NewHandler(ctx context.Context, postgres Pinger, redis *storage.Breaker, log *slog.Logger) *Handler {
+	return &Handler{
+		Log:      log,
+		Postgres: postgres,
+		Redis:    redis,
+	}
+}
+
+// Report is the state of the service and of each dependency.
+type Report struct {
+	Status   string              `json:"status" example:"degraded"`
+	Postgres bool                `json:"postgres"`
+	Redis    storage.RedisStatus `json:"redis"`
+}
+
+// Check @Summary Health check
+// @Description Reports whether Postgres and Redis are reachable. Without Redis the service keeps working on per-replica caches and limits and reports "degraded" with 200; without Postgres it reports "down" with 503.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} Report "ok or degraded"
+// @Failure 503 {object} Report "Postgres is unavailable"
+// @Router /health [get]
+func (h *Handler) Check(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Postgres: true, Redis: h.Redis.Status()}
+	if !report.Redis.Available {
+		report.Status = StatusDegraded
+	}
+	if err := h.Postgres.Ping(ctx); err != nil {
+		h.Log.Error("health check: postgres unavailable", sl.Err(err))
+		report.Postgres = false
+		report.Status = StatusDown
+	}
+
+	status := http.StatusOK
+	if report.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	render.Status(r, status)
+	render.JSON(w, r, response.Response{
+		StatusCode: status,
+		Message:    report.Status,
+		Data:       report,
+	})
+}
@@ -0,0 +1,95 @@
+package public
+
+import (
+	"complaint_server/internal/domain"
+	serviceComplaint "complaint_server/internal/service/complaint"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"context"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Handler serves endpoints that need no authentication.
+type Handler struct {
+	Log              *slog.Logger
+	ComplaintService *serviceComplaint.ComplaintService
+}
+
+func NewHandler(ctx context.Context, complaintService *serviceComplaint.ComplaintService, log *slog.Logger) *Handler {
+	return &Handler{
+		ComplaintService: complaintService,
+		Log:              log,
+	}
+}
+
+// GetBoard @Summary Public board of resolved complaints
+// @Description Resolved complaints of public categories that aren't archived, newest resolution first. Barcodes are not shown and personal data is removed from the text.
+// @Description Complaints hidden by an admin, and duplicates merged into another complaint, are left out. Pages are cached for up to a minute.
+// @Tags Public
+// @Produce json
+// @Param page query int false "Page number, from 1" default(1)
+// @Param page_size query int false "Complaints per page, up to 100" default(20)
+// @Param category_id query string false "Only complaints of this category"
+// @Success 200 {object} domain.BoardPage "Page of complaints"
+// @Failure 400 {object} response.Response "Invalid parameters"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /public/complaints [get]
+func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.public.get_board.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	query := r.URL.Query()
+	filter := domain.BoardFilter{Page: 1, PageSize: defaultPageSize}
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("page must be a positive number", http.StatusBadRequest))
+			return
+		}
+		filter.Page = page
+	}
+	if raw := query.Get("page_size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > maxPageSize {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("page_size must be between 1 and 100", http.StatusBadRequest))
+			return
+		}
+		filter.PageSize = size
+	}
+	if raw := query.Get("category_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid category_id", http.StatusBadRequest))
+			return
+		}
+		filter.CategoryID = &id
+	}
+
+	page, err := h.ComplaintService.GetBoard(r.Context(), filter)
+	if err != nil {
+		log.Error("failed to get board", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       page,
+	})
+}
@@ -0,0 +1,340 @@
+package templates
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	serviceAdmin "complaint_server/internal/service/admin"
+	serviceTemplate "complaint_server/internal/service/template"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	Log             *slog.Logger
+	AdminService    *serviceAdmin.AdminService
+	TemplateService *serviceTemplate.TemplateService
+	Cfg             *config.Config
+}
+
+func NewHandler(ctx context.Context, templateService *serviceTemplate.TemplateService, adminService *serviceAdmin.AdminService, log *slog.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		AdminService:    adminService,
+		TemplateService: templateService,
+		Log:             log,
+		Cfg:             cfg,
+	}
+}
+
+type Request struct {
+	Title      string     `json:"title" validate:"required,max=200" example:"Hot water restored"`
+	Body       string     `json:"body" validate:"required,max=4000" example:"Dear {{student_barcode}}, hot water is back. Ticket {{ticket_number}}."`
+	CategoryID *uuid.UUID `json:"category_id"` // Leave empty for a template usable in every category
+}
+
+func (req Request) template() domain.AnswerTemplate {
+	return domain.AnswerTemplate{
+		Title:      req.Title,
+		Body:       req.Body,
+		CategoryID: req.CategoryID,
+	}
+}
+
+type PreviewRequest struct {
+	TemplateID  *uuid.UUID        `json:"template_id"`
+	Body        string            `json:"body" example:"Ticket {{ticket_number}} is resolved"` // Used when template_id is empty
+	ComplaintID uuid.UUID         `json:"complaint_id" validate:"required"`
+	Variables   map[string]string `json:"variables" example:"room:C-214"`
+}
+
+// Create @Summary Create an answer template
+// @Description Templates use text/template syntax. Available placeholders: {{student_barcode}}, {{category}}, {{created_at}}, {{ticket_number}}; other values are read with {{var "name"}} from the variables given when answering.
+// @Description Helpers: upper, lower, default. Loops and sub-templates are not allowed.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Param request body Request true "Template"
+// @Success 200 {object} response.Response "Template created"
+// @Failure 400 {object} response.Response "Invalid request or template syntax"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.create.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := h.decode(w, r, log)
+	if !ok {
+		return
+	}
+
+	template, err := h.TemplateService.Create(r.Context(), req.template())
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("answer template created", slog.Any("id", template.ID))
+	render.JSON(w, r, response.Response{
+		Message:    "Answer template created successfully",
+		StatusCode: http.StatusOK,
+		Data:       template,
+	})
+}
+
+// GetAll @Summary List answer templates
+// @Description Returns the global templates, and the templates of a category when category_id is given. Without it, all templates are returned.
+// @Tags Templates
+// @Produce json
+// @Param category_id query string false "Category ID"
+// @Success 200 {array} domain.AnswerTemplate "Templates"
+// @Failure 400 {object} response.Response "Invalid category_id"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin [get]
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.get_all.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	var categoryID *uuid.UUID
+	if raw := r.URL.Query().Get("category_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid category_id", http.StatusBadRequest))
+			return
+		}
+		categoryID = &id
+	}
+
+	templates, err := h.TemplateService.GetAll(r.Context(), categoryID)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	if templates == nil {
+		templates = []domain.AnswerTemplate{}
+	}
+	render.JSON(w, r, response.Response{
+		Message:    "Answer templates fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       templates,
+	})
+}
+
+// GetById @Summary Get an answer template
+// @Tags Templates
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 200 {object} domain.AnswerTemplate "Template"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Template not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin/{id} [get]
+func (h *Handler) GetById(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.get_by_id.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log)
+	if !ok {
+		return
+	}
+
+	template, err := h.TemplateService.GetByID(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		Message:    "Answer template fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       template,
+	})
+}
+
+// Update @Summary Update an answer template
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param request body Request true "Template"
+// @Success 200 {object} response.Response "Template updated"
+// @Failure 400 {object} response.Response "Invalid request or template syntax"
+// @Failure 404 {object} response.Response "Template not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin/{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.update.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, ok := h.parseID(w, r, log)
+	if !ok {
+		return
+	}
+	req, ok := h.decode(w, r, log)
+	if !ok {
+		return
+	}
+
+	err := h.TemplateService.Update(r.Context(), id, req.template())
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("answer template updated", slog.Any("id", id))
+	render.JSON(w, r, response.Response{
+		Message:    "Answer template updated successfully",
+		StatusCode: http.StatusOK,
+		Data:       map[string]interface{}{"id": id},
+	})
+}
+
+// Delete @Summary Delete an answer template
+// @Tags Templates
+// @Param id path string true "Template ID"
+// @Success 200 {object} response.Response "Template deleted"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Template not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.delete.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log)
+	if !ok {
+		return
+	}
+
+	err := h.TemplateService.Delete(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("answer template deleted", slog.Any("id", id))
+	render.JSON(w, r, response.Response{
+		Message:    "Answer template deleted successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+// Preview @Summary Preview an answer
+// @Description Renders a stored template, or an unsaved body, for an existing complaint without changing anything.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Param request body PreviewRequest true "Template or body, complaint and variables"
+// @Success 200 {object} response.Response "data is the rendered answer"
+// @Failure 400 {object} response.Response "Invalid request, template syntax or missing variable"
+// @Failure 404 {object} response.Response "Template or complaint not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /templates/admin/preview [post]
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.templates.preview.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req PreviewRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return
+	}
+	if err := validator.New().Struct(req); err != nil || (req.TemplateID == nil && req.Body == "") {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("complaint_id and either template_id or body are required", http.StatusBadRequest))
+		return
+	}
+
+	answer, err := h.TemplateService.Preview(r.Context(), req.TemplateID, req.Body, req.ComplaintID, req.Variables)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusOK,
+		Data:       answer,
+	})
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger) (Request, bool) {
+	var req Request
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return Request{}, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			log.Error("validation failed", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return Request{}, false
+		}
+		log.Error("unknown validation error", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return Request{}, false
+	}
+	return req, true
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id", http.StatusBadRequest))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError writes the response for err and reports whether the handler must stop.
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrTemplateNotFound), errors.Is(err, storage.ErrComplaintNotFound),
+		errors.Is(err, storage.ErrCategoryNotFound):
+		log.Error("not found", sl.Err(err))
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusNotFound))
+	case errors.Is(err, serviceTemplate.ErrInvalidTemplate), errors.Is(err, serviceTemplate.ErrRender),
+		errors.Is(err, serviceTemplate.ErrWrongCategory):
+		log.Info("template rejected", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+	default:
+		log.Error("internal error", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+	}
+	return true
+}
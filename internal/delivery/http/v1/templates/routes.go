@@ -0,0 +1,18 @@
+package templates
+
+import (
+	"complaint_server/internal/delivery/http/middleware/admin"
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(admin.AdminOnlyMiddleware(h.Log, h.Cfg, h.AdminService))
+		r.Post("/", h.Create)
+		r.Get("/", h.GetAll)
+		r.Post("/preview", h.Preview)
+		r.Get("/{id}", h.GetById)
+		r.Put("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+	})
+}
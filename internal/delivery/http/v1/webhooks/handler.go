@@ -0,0 +1,397 @@
+package webhooks
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	serviceAdmin "complaint_server/internal/service/admin"
+	serviceWebhook "complaint_server/internal/service/webhook"
+	"complaint_server/internal/shared/api/response"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	Log            *slog.Logger
+	AdminService   *serviceAdmin.AdminService
+	WebhookService *serviceWebhook.WebhookService
+	Cfg            *config.Config
+}
+
+func NewHandler(ctx context.Context, webhookService *serviceWebhook.WebhookService, adminService *serviceAdmin.AdminService, log *slog.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		AdminService:   adminService,
+		WebhookService: webhookService,
+		Log:            log,
+		Cfg:            cfg,
+	}
+}
+
+type Request struct {
+	URL        string             `json:"url" validate:"required,url" example:"https://hooks.example.com/complaints"`
+	Secret     string             `json:"secret" example:"whsec_3f9a..."`
+	EventTypes []domain.EventType `json:"event_types" validate:"required,min=1,dive,oneof=complaint.created complaint.updated complaint.status_changed complaint.deleted"`
+	CategoryID *uuid.UUID         `json:"category_id"`
+	Active     *bool              `json:"active" example:"true"`
+}
+
+func (req Request) subscription() domain.WebhookSubscription {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return domain.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		CategoryID: req.CategoryID,
+		Active:     active,
+	}
+}
+
+// Create @Summary Create a webhook subscription
+// @Description Subscribes a URL to complaint lifecycle events. If no secret is given, one is generated and returned once.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body Request true "Subscription details"
+// @Success 200 {object} response.Response "Subscription created, data contains the subscription with its secret"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.create.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := h.decode(w, r, log)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.WebhookService.CreateSubscription(r.Context(), req.subscription())
+	if err != nil {
+		log.Error("failed to create webhook subscription", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create webhook subscription", http.StatusInternalServerError))
+		return
+	}
+
+	log.Info("webhook subscription created", slog.Any("id", subscription.ID))
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook subscription created successfully",
+		StatusCode: http.StatusOK,
+		Data:       subscription,
+	})
+}
+
+// GetAll @Summary List webhook subscriptions
+// @Description Returns all webhook subscriptions. Secrets are not included.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} domain.WebhookSubscription "List of subscriptions"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin [get]
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.get_all.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	subscriptions, err := h.WebhookService.GetSubscriptions(r.Context())
+	if err != nil {
+		log.Error("failed to get webhook subscriptions", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []domain.WebhookSubscription{}
+	}
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook subscriptions fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       subscriptions,
+	})
+}
+
+// GetById @Summary Get a webhook subscription
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} domain.WebhookSubscription "Subscription details"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Subscription not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/{id} [get]
+func (h *Handler) GetById(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.get_by_id.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.WebhookService.GetSubscriptionByID(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook subscription fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       subscription,
+	})
+}
+
+// Update @Summary Update a webhook subscription
+// @Description Replaces URL, event types, category filter and active flag. The secret is rotated only when a new one is given.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param request body Request true "Subscription details"
+// @Success 200 {object} response.Response "Subscription updated"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Subscription not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.update.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, ok := h.parseID(w, r, log, "id")
+	if !ok {
+		return
+	}
+	req, ok := h.decode(w, r, log)
+	if !ok {
+		return
+	}
+
+	err := h.WebhookService.UpdateSubscription(r.Context(), id, req.subscription())
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("webhook subscription updated", slog.Any("id", id))
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook subscription updated successfully",
+		StatusCode: http.StatusOK,
+		Data:       map[string]interface{}{"id": id},
+	})
+}
+
+// Delete @Summary Delete a webhook subscription
+// @Tags Webhooks
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} response.Response "Subscription deleted"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Subscription not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.delete.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log, "id")
+	if !ok {
+		return
+	}
+
+	err := h.WebhookService.DeleteSubscription(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("webhook subscription deleted", slog.Any("id", id))
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook subscription deleted successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+// GetDeliveries @Summary Webhook delivery log
+// @Description Returns the latest deliveries of a subscription, optionally filtered by status.
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param status query string false "Delivery status (pending, delivered, dead)"
+// @Success 200 {array} domain.WebhookDelivery "Deliveries"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Subscription not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/{id}/deliveries [get]
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.get_deliveries.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	id, ok := h.parseID(w, r, log, "id")
+	if !ok {
+		return
+	}
+
+	status := domain.DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryDead:
+	default:
+		log.Error("invalid delivery status", slog.String("status", string(status)))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid delivery status", http.StatusBadRequest))
+		return
+	}
+
+	deliveries, err := h.WebhookService.GetDeliveries(r.Context(), id, status)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook deliveries fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       deliveries,
+	})
+}
+
+// GetDeadLetters @Summary Dead-lettered webhook deliveries
+// @Description Returns deliveries of all subscriptions that failed after the maximum number of attempts.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} domain.WebhookDelivery "Dead deliveries"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/dead-letters [get]
+func (h *Handler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.get_dead_letters.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("url", r.URL.String()),
+	)
+
+	deliveries, err := h.WebhookService.GetDeadLetters(r.Context())
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	render.JSON(w, r, response.Response{
+		Message:    "Dead-lettered deliveries fetched successfully",
+		StatusCode: http.StatusOK,
+		Data:       deliveries,
+	})
+}
+
+// Redeliver @Summary Redeliver a webhook
+// @Description Puts a delivered or dead-lettered delivery back into the queue so that it is sent again.
+// @Tags Webhooks
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} response.Response "Delivery queued"
+// @Failure 400 {object} response.Response "Invalid ID format"
+// @Failure 404 {object} response.Response "Delivery not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /webhooks/admin/deliveries/{deliveryId}/redeliver [post]
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhooks.redeliver.New"
+	log := h.Log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, ok := h.parseID(w, r, log, "deliveryId")
+	if !ok {
+		return
+	}
+
+	err := h.WebhookService.Redeliver(r.Context(), id)
+	if h.handleError(w, r, log, err) {
+		return
+	}
+
+	log.Info("webhook delivery queued again", slog.Any("delivery_id", id))
+	render.JSON(w, r, response.Response{
+		Message:    "Webhook delivery queued",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger) (Request, bool) {
+	var req Request
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode request body", http.StatusBadRequest))
+		return Request{}, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			log.Error("validation failed", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validationErrors))
+			return Request{}, false
+		}
+		log.Error("unknown validation error", sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Unknown validation error", http.StatusBadRequest))
+		return Request{}, false
+	}
+	return req, true
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request, log *slog.Logger, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		log.Error("invalid id", slog.String("param", param), sl.Err(err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid "+param, http.StatusBadRequest))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError writes the response for err and reports whether the handler must stop.
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrSubscriptionNotFound), errors.Is(err, storage.ErrDeliveryNotFound):
+		log.Error("not found", sl.Err(err))
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusNotFound))
+	default:
+		log.Error("internal error", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error", http.StatusInternalServerError))
+	}
+	return true
+}
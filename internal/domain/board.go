@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// BoardComplaint is a resolved complaint as shown on the public board: without the barcode
+// and with personal data removed from the message.
+type BoardComplaint struct {
+	ID                uuid.UUID `json:"id"`
+	CategoryID        uuid.UUID `json:"category_id"`
+	Category          string    `json:"category" example:"Dormitory"`
+	Message           string    `json:"message" example:"No hot water in block C since Monday"`
+	Status            string    `json:"status" example:"approved"`
+	Answer            string    `json:"answer" example:"Hot water has been restored"`
+	Endorsements      int       `json:"endorsements" example:"12"`
+	CreatedAt         time.Time `json:"created_at"`
+	ResolvedAt        time.Time `json:"resolved_at"`
+	ResolutionSeconds int64     `json:"resolution_seconds" example:"86400"`
+}
+
+type BoardFilter struct {
+	CategoryID *uuid.UUID
+	Page       int // 1-based
+	PageSize   int
+}
+
+type BoardPage struct {
+	Items    []BoardComplaint `json:"items"`
+	Page     int              `json:"page" example:"1"`
+	PageSize int              `json:"page_size" example:"20"`
+	Total    int64            `json:"total" example:"134"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type BulkOutcome string
+
+const (
+	BulkUpdated  BulkOutcome = "updated"
+	BulkSkipped  BulkOutcome = "skipped" // The lifecycle doesn't allow the change
+	BulkNotFound BulkOutcome = "not_found"
+	BulkMerged   BulkOutcome = "merged"
+)
+
+// BulkStatusUpdate changes the status and answer of the complaints listed in IDs,
+// or of the complaints matching Filter when IDs is empty.
+type BulkStatusUpdate struct {
+	IDs    []uuid.UUID
+	Filter ComplaintFilter
+	Status ComplaintStatus
+	Answer string
+	// AnswerFor, when set, gives each complaint its own answer instead of Answer.
+	// Complaints it fails for are skipped.
+	AnswerFor func(complaint Complaint) (string, error)
+	Actor     int // Barcode of the admin
+}
+
+type BulkItemResult struct {
+	ID         uuid.UUID       `json:"id"`
+	Outcome    BulkOutcome     `json:"outcome" example:"updated"`
+	FromStatus ComplaintStatus `json:"from_status,omitempty" example:"pending"`
+	Message    string          `json:"message,omitempty" example:"complaint is already approved"`
+}
+
+type BulkResult struct {
+	BatchID  uuid.UUID        `json:"batch_id"`
+	Updated  int              `json:"updated"`
+	Skipped  int              `json:"skipped"`
+	NotFound int              `json:"not_found"`
+	Items    []BulkItemResult `json:"items"`
+}
+
+// AuditEntry records one change made by an admin.
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	ComplaintID uuid.UUID       `json:"complaint_id"`
+	Actor       int             `json:"actor" example:"242590"`
+	Action      string          `json:"action" example:"bulk_status_change"`
+	FromStatus  ComplaintStatus `json:"from_status" example:"pending"`
+	ToStatus    ComplaintStatus `json:"to_status" example:"approved"`
+	Answer      string          `json:"answer"`
+	BatchID     *uuid.UUID      `json:"batch_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -1,10 +1,31 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Category struct {
 	ID          uuid.UUID `json:"id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"` // Detailed description of the categories
 	Answer      string    `json:"answer"`
+	Archived    bool      `json:"archived,omitempty"` // Hidden from the category list, kept for existing complaints
+	Public      bool      `json:"public"`             // Complaints can be seen and endorsed by other students
+	// Row version for ETag and If-Match. Unlike CategoryVersion numbers it also changes with
+	// visibility and archiving.
+	Version int `json:"version" example:"4"`
+}
+
+// CategoryVersion is the content of a category between two edits. A new version is
+// recorded whenever the title, description or answer changes.
+type CategoryVersion struct {
+	ID          int64     `json:"id" example:"42"`
+	CategoryID  uuid.UUID `json:"category_id"`
+	Version     int       `json:"version" example:"3"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Answer      string    `json:"answer"`
+	CreatedAt   time.Time `json:"created_at" example:"2025-04-21T12:00:00Z"`
 }
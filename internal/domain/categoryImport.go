@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"strings"
+)
+
+// CategoryDocument is the YAML/JSON file format of the category import and export.
+type CategoryDocument struct {
+	Categories []CategoryEntry `json:"categories" yaml:"categories"`
+}
+
+type CategoryEntry struct {
+	Title       string `json:"title" yaml:"title" example:"Dormitory"`
+	Description string `json:"description" yaml:"description" example:"Problems with dormitory conditions"`
+	Answer      string `json:"answer" yaml:"answer" example:"Your complaint was sent to the dormitory office"`
+	Archived    bool   `json:"archived,omitempty" yaml:"archived,omitempty"`
+}
+
+type ImportAction string
+
+const (
+	ImportCreate    ImportAction = "create"
+	ImportUpdate    ImportAction = "update"
+	ImportArchive   ImportAction = "archive"
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+type CategoryChange struct {
+	Action   ImportAction   `json:"action" example:"update"`
+	ID       *uuid.UUID     `json:"id,omitempty"`
+	Title    string         `json:"title" example:"Dormitory"`
+	Fields   []string       `json:"fields,omitempty" example:"answer"` // Changed fields of an update
+	Entry    *CategoryEntry `json:"-"`
+	Row      int            `json:"row,omitempty" example:"3"` // 1-based position in the document
+	Category Category       `json:"-"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row" example:"3"`
+	Title   string `json:"title" example:"Dormitory"`
+	Message string `json:"message" example:"answer is required"`
+}
+
+type CategoryImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Archived  int              `json:"archived"`
+	Unchanged int              `json:"unchanged"`
+	Changes   []CategoryChange `json:"changes"`
+	Errors    []ImportRowError `json:"errors,omitempty"`
+}
+
+// PlanCategoryImport compares the imported entries with the existing categories by title
+// (case-insensitively) and lists what has to change. Existing categories that are not in the
+// document are archived when archiveMissing is set.
+func PlanCategoryImport(existing []Category, entries []CategoryEntry, archiveMissing bool) CategoryImportResult {
+	byTitle := make(map[string]Category, len(existing))
+	for _, category := range existing {
+		byTitle[strings.ToLower(category.Title)] = category
+	}
+
+	var result CategoryImportResult
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		key := strings.ToLower(entry.Title)
+		seen[key] = true
+
+		category, ok := byTitle[key]
+		if !ok {
+			result.Changes = append(result.Changes, CategoryChange{Action: ImportCreate, Title: entry.Title, Entry: &entries[i], Row: i + 1})
+			result.Created++
+			continue
+		}
+
+		id := category.ID
+		change := CategoryChange{ID: &id, Title: entry.Title, Entry: &entries[i], Row: i + 1, Category: category}
+		if category.Title != entry.Title {
+			change.Fields = append(change.Fields, "title")
+		}
+		if category.Description != entry.Description {
+			change.Fields = append(change.Fields, "description")
+		}
+		if category.Answer != entry.Answer {
+			change.Fields = append(change.Fields, "answer")
+		}
+		if category.Archived != entry.Archived {
+			change.Fields = append(change.Fields, "archived")
+		}
+
+		if len(change.Fields) == 0 {
+			change.Action = ImportUnchanged
+			result.Unchanged++
+		} else {
+			change.Action = ImportUpdate
+			result.Updated++
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	if archiveMissing {
+		for _, category := range existing {
+			if category.Archived || seen[strings.ToLower(category.Title)] {
+				continue
+			}
+			id := category.ID
+			result.Changes = append(result.Changes, CategoryChange{Action: ImportArchive, ID: &id, Title: category.Title, Category: category})
+			result.Archived++
+		}
+	}
+	return result
+}
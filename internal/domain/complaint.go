@@ -2,6 +2,8 @@ package domain
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"time"
 )
@@ -15,12 +17,73 @@ type Complaint struct {
 	CreatedAt time.Time      `json:"created_at" example:"2025-04-21T12:00:00Z"`
 	UpdatedAt time.Time      `json:"updated_at" example:"2025-04-21T14:00:00Z"`
 	Answer    sql.NullString `json:"answer" swaggertype:"string" example:"Complaint resolved"`
+	// Number of students who backed the complaint, see EndorseComplaint.
+	Endorsements int `json:"endorsements" example:"12"`
+	// Set on a duplicate merged into another complaint, whose status and answer it follows.
+	MergedInto *uuid.UUID `json:"merged_into,omitempty"`
+	// Detectors in flag mode that matched the message, e.g. "profanity".
+	ContentFlags []string `json:"content_flags,omitempty"`
+	// Set once the owner has edited the complaint, see GET /complaints/{id}/revisions.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// Row version, sent as the ETag and expected back in If-Match.
+	Version int `json:"version" example:"3"`
+	// The category as it was when the complaint was submitted, with the answer the student
+	// was shown. Only set on complaint detail.
+	CategoryVersion *CategoryVersion `json:"category_version,omitempty"`
 }
 
 type ComplaintStatus string
 
+// The values match the complaint_status enum in the database.
 const (
-	StatusApproved ComplaintStatus = "Approved"
-	StatusPending  ComplaintStatus = "Pending"
-	StatusRejected ComplaintStatus = "Rejected"
+	StatusApproved ComplaintStatus = "approved"
+	StatusPending  ComplaintStatus = "pending"
+	StatusRejected ComplaintStatus = "rejected"
 )
+
+var ErrInvalidTransition = errors.New("status transition is not allowed")
+
+// CheckTransition enforces the complaint lifecycle: a pending complaint may be approved or
+// rejected, a decided complaint may only be sent back to pending to reopen it.
+func CheckTransition(from ComplaintStatus, to ComplaintStatus) error {
+	switch {
+	case from == to:
+		return fmt.Errorf("%w: complaint is already %s", ErrInvalidTransition, to)
+	case from == StatusPending && (to == StatusApproved || to == StatusRejected):
+		return nil
+	case (from == StatusApproved || from == StatusRejected) && to == StatusPending:
+		return nil
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+}
+
+// Endorsement is the state of a student's "me too" on a complaint.
+type Endorsement struct {
+	ComplaintID  uuid.UUID `json:"complaint_id"` // The primary complaint when the endorsed one was merged
+	Endorsed     bool      `json:"endorsed" example:"true"`
+	Endorsements int       `json:"endorsements" example:"13"`
+}
+
+// ComplaintOrder sorts a complaint listing; the default leaves the order to the database.
+type ComplaintOrder string
+
+const (
+	OrderDefault      ComplaintOrder = ""
+	OrderEndorsements ComplaintOrder = "endorsements" // Most endorsed first, newest first among equals
+)
+
+// ParseComplaintOrder reads a sort query parameter; unknown values keep the default order.
+func ParseComplaintOrder(sort string) ComplaintOrder {
+	if sort == string(OrderEndorsements) {
+		return OrderEndorsements
+	}
+	return OrderDefault
+}
+
+// ComplaintFilter narrows a complaint listing. Empty fields match everything.
+type ComplaintFilter struct {
+	Status     string
+	CategoryID *uuid.UUID
+	From       *time.Time // created_at, inclusive
+	To         *time.Time // created_at, exclusive
+}
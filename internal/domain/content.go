@@ -0,0 +1,17 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ContentReview is what the content pipeline found in a new complaint's message.
+type ContentReview struct {
+	Flags []string
+	// Encrypted text as submitted, set only when masking changed it.
+	SealedOriginal []byte
+}
+
+// OriginalText is the message of a complaint as the student submitted it, before masking.
+type OriginalText struct {
+	ComplaintID  uuid.UUID `json:"complaint_id"`
+	Message      string    `json:"message" example:"Call me at +7 701 123 45 67"`
+	ContentFlags []string  `json:"content_flags,omitempty"`
+}
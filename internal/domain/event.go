@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventComplaintCreated       EventType = "complaint.created"
+	EventComplaintUpdated       EventType = "complaint.updated"
+	EventComplaintStatusChanged EventType = "complaint.status_changed"
+	EventComplaintDeleted       EventType = "complaint.deleted"
+)
+
+// Event describes a change in the complaint lifecycle that other systems can react to.
+type Event struct {
+	ID         uuid.UUID `json:"id" example:"5b0f6a4e-2f4e-4a8e-9b1a-0c6f7b2d9e11"`
+	Type       EventType `json:"type" example:"complaint.created"`
+	OccurredAt time.Time `json:"occurred_at" example:"2025-04-21T12:00:00Z"`
+	Complaint  Complaint `json:"complaint"`
+}
+
+func NewEvent(eventType EventType, complaint Complaint) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Complaint:  complaint,
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ExportStatus string
+
+const (
+	ExportQueued  ExportStatus = "queued"
+	ExportRunning ExportStatus = "running"
+	ExportDone    ExportStatus = "done"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// ExportParams are the filters and output options of an export job.
+type ExportParams struct {
+	Format       string     `json:"format" example:"csv"`
+	Columns      []string   `json:"columns,omitempty" example:"id,status,created_at"`
+	Timezone     string     `json:"tz,omitempty" example:"Asia/Almaty"`
+	Pseudonymize bool       `json:"pseudonymize" example:"false"`
+	Status       string     `json:"status,omitempty" example:"approved"`
+	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
+	From         string     `json:"from,omitempty" example:"2025-01-01"` // YYYY-MM-DD, inclusive
+	To           string     `json:"to,omitempty" example:"2025-12-31"`   // YYYY-MM-DD, inclusive
+}
+
+type ExportJob struct {
+	ID          uuid.UUID    `json:"id"`
+	Status      ExportStatus `json:"status" example:"running"`
+	Params      ExportParams `json:"params"`
+	RequestedBy int          `json:"requested_by" example:"242590"`
+	Total       int64        `json:"total" example:"12000"`
+	Rows        int64        `json:"rows" example:"4500"`
+	FileSize    int64        `json:"file_size" example:"0"`
+	FilePath    string       `json:"-"`
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	StartedAt   *time.Time   `json:"started_at,omitempty"`
+	FinishedAt  *time.Time   `json:"finished_at,omitempty"`
+	// Set on finished jobs when they are returned to a client.
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"download_expires_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// OutboxMessage is an event stored in the same transaction as the change that caused it,
+// waiting to be handed to the event sinks.
+type OutboxMessage struct {
+	ID        int64     `json:"id"`
+	Event     Event     `json:"event"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OutboxStats struct {
+	Backlog   int64         `json:"backlog"`
+	OldestAge time.Duration `json:"oldest_age"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ComplaintPatch is an admin's partial update of a complaint. Nil fields are left as they are;
+// an empty Answer clears the answer.
+type ComplaintPatch struct {
+	Status     *ComplaintStatus
+	Answer     *string
+	CategoryID *uuid.UUID
+}
+
+// CategoryPatch is a partial update of a category. Nil fields are left as they are.
+type CategoryPatch struct {
+	Title       *string
+	Description *string
+	Answer      *string
+	Public      *bool
+}
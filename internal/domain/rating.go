@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Rating is the owner's satisfaction with how a resolved complaint was handled.
+// Resolved false means the student says the problem is still there; the complaint is reopened.
+type Rating struct {
+	ComplaintID uuid.UUID `json:"complaint_id"`
+	Rating      int       `json:"rating" example:"4"`
+	Comment     string    `json:"comment" example:"Fixed, but it took a week"`
+	Resolved    bool      `json:"resolved" example:"true"`
+	Reopened    bool      `json:"reopened" example:"false"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CSAT summarizes ratings. Satisfied counts ratings of 4 and 5.
+type CSAT struct {
+	Responses     int64   `json:"responses" example:"40"`
+	Average       float64 `json:"average" example:"4.1"`
+	SatisfiedRate float64 `json:"satisfied_rate" example:"0.75"`
+	NotResolved   int64   `json:"not_resolved" example:"3"`
+}
+
+type CategoryCSAT struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Title      string    `json:"title" example:"Dormitory"`
+	CSAT
+}
+
+// AdminCSAT groups ratings by the admin who first resolved the complaint.
+type AdminCSAT struct {
+	Barcode int `json:"barcode" example:"100234"`
+	CSAT
+}
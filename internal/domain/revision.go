@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ComplaintEdit holds the fields the owner changes on a pending complaint. Nil fields are kept.
+type ComplaintEdit struct {
+	Message    *string
+	CategoryID *uuid.UUID
+}
+
+// ComplaintRevision is the content of a complaint after an edit. Revision 1 is the complaint as submitted.
+type ComplaintRevision struct {
+	Revision     int       `json:"revision" example:"2"`
+	Message      string    `json:"message" example:"Too noisy in the dorm after 11pm"`
+	CategoryID   uuid.UUID `json:"category_id"`
+	Category     string    `json:"category" example:"Dormitory"`
+	ContentFlags []string  `json:"content_flags,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Word changes to the message and whether the category changed, compared with the previous revision.
+	Changes         []TextChange `json:"changes,omitempty"`
+	CategoryChanged bool         `json:"category_changed" example:"false"`
+}
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+type TextChange struct {
+	Op   DiffOp `json:"op" example:"insert"`
+	Text string `json:"text" example:"after 11pm"`
+}
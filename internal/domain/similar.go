@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+type SimilarityOptions struct {
+	Threshold float64       // Minimum trigram similarity
+	Window    time.Duration // Only complaints created this close to the reference
+	Limit     int
+	FullText  bool // Also match complaints that contain all words of the other message
+}
+
+type SimilarComplaint struct {
+	Complaint  Complaint `json:"complaint"`
+	Similarity float64   `json:"similarity" example:"0.62"`
+	TextMatch  bool      `json:"text_match" example:"false"`
+}
+
+// DuplicateHint tells a student that their complaint may already have been reported.
+type DuplicateHint struct {
+	PossiblyAlreadyReported bool `json:"possibly_already_reported" example:"true"`
+	SimilarComplaints       int  `json:"similar_complaints" example:"3"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type StatsInterval string
+
+const (
+	IntervalDay   StatsInterval = "day"
+	IntervalWeek  StatsInterval = "week"
+	IntervalMonth StatsInterval = "month"
+)
+
+type StatsFilter struct {
+	From       *time.Time // inclusive
+	To         *time.Time // exclusive
+	CategoryID *uuid.UUID
+	Interval   StatsInterval
+}
+
+type CategoryCount struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Title      string    `json:"title" example:"Dormitory"`
+	Count      int64     `json:"count" example:"42"`
+}
+
+type SeriesPoint struct {
+	Period time.Time `json:"period" example:"2025-04-21T00:00:00Z"`
+	Count  int64     `json:"count" example:"7"`
+}
+
+// DurationStats holds percentiles in seconds over the complaints that have the measured timestamp.
+type DurationStats struct {
+	MedianSeconds float64 `json:"median_seconds" example:"5400"`
+	P90Seconds    float64 `json:"p90_seconds" example:"86400"`
+	Samples       int64   `json:"samples" example:"120"`
+}
+
+type ComplaintStats struct {
+	Total             int64            `json:"total" example:"250"`
+	ByStatus          map[string]int64 `json:"by_status"`
+	ByCategory        []CategoryCount  `json:"by_category"`
+	Interval          StatsInterval    `json:"interval" example:"day"`
+	Series            []SeriesPoint    `json:"series"`
+	TimeToFirstAnswer DurationStats    `json:"time_to_first_answer"`
+	TimeToResolution  DurationStats    `json:"time_to_resolution"`
+	ApprovalRatio     float64          `json:"approval_ratio" example:"0.8"`
+	RejectionRatio    float64          `json:"rejection_ratio" example:"0.2"`
+	CSAT              CSAT             `json:"csat"`
+	CSATByCategory    []CategoryCSAT   `json:"csat_by_category"`
+	CSATByAdmin       []AdminCSAT      `json:"csat_by_admin"`
+}
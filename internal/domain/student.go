@@ -3,3 +3,10 @@ package domain
 type Student struct {
 	Barcode int `json:"barcode"`
 }
+
+// StudentContact tells where and in which language to notify a student.
+type StudentContact struct {
+	Barcode int    `json:"barcode" example:"242590"`
+	Email   string `json:"email" example:"242590@astanait.edu.kz"`
+	Locale  string `json:"locale" example:"ru"`
+}
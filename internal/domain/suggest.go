@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CategorySuggestion is a category proposed for a draft complaint.
+type CategorySuggestion struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Title      string    `json:"title" example:"Dormitory"`
+	// Combined keyword and classifier score, 0..1.
+	Score float64 `json:"score" example:"0.82"`
+	// Keyword rules of the category that matched the message.
+	Keywords []string `json:"keywords,omitempty" example:"dorm"`
+}
+
+// TrainingSample is a past complaint message with the category it ended up in.
+type TrainingSample struct {
+	CategoryID uuid.UUID
+	Message    string
+}
+
+// KeywordRule lists phrases that point to a category whenever they appear in a message.
+type KeywordRule struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Keywords   []string  `json:"keywords" example:"dorm,roommate"`
+}
+
+// SuggestionAccuracy compares the category suggested at submission with the category each
+// complaint has now, after any admin corrections.
+type SuggestionAccuracy struct {
+	// Complaints with a recorded suggestion.
+	Suggested int64 `json:"suggested" example:"1200"`
+	// Of those, complaints whose current category is the suggested one.
+	Correct  int64   `json:"correct" example:"960"`
+	Accuracy float64 `json:"accuracy" example:"0.8"`
+	// Complaints an admin moved away from the category the student picked.
+	Corrected int64 `json:"corrected" example:"150"`
+	// Of the corrected complaints, those where the suggestion was the admin's category.
+	CorrectedPredicted int64   `json:"corrected_predicted" example:"110"`
+	CorrectionRecall   float64 `json:"correction_recall" example:"0.73"`
+	// The model in use.
+	ModelTrainedAt *time.Time `json:"model_trained_at,omitempty"`
+	ModelSamples   int        `json:"model_samples" example:"5400"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnswerTemplate is a canned admin answer written in text/template syntax,
+// e.g. "Complaint {{ticket_number}} about {{category}} is resolved".
+type AnswerTemplate struct {
+	ID         uuid.UUID  `json:"id" example:"5d1b2c3a-8e7f-4a6b-9c0d-1e2f3a4b5c6d"`
+	Title      string     `json:"title" example:"Hot water restored"`
+	Body       string     `json:"body" example:"Dear {{student_barcode}}, hot water is back. Ticket {{ticket_number}}."`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"` // Usable for every category when empty
+	CreatedAt  time.Time  `json:"created_at" example:"2025-04-21T12:00:00Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2025-04-21T14:00:00Z"`
+}
+
+// AppliesTo reports whether the template may answer complaints of the category.
+func (t AnswerTemplate) AppliesTo(categoryID uuid.UUID) bool {
+	return t.CategoryID == nil || *t.CategoryID == categoryID
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID         uuid.UUID   `json:"id" example:"0e4c6f1a-7d8b-4c1e-9a55-3b2f1d0c9e88"`
+	URL        string      `json:"url" example:"https://hooks.example.com/complaints"`
+	Secret     string      `json:"secret,omitempty" example:"whsec_3f9a..."`
+	EventTypes []EventType `json:"event_types" example:"complaint.created,complaint.status_changed"`
+	CategoryID *uuid.UUID  `json:"category_id,omitempty"` // Only events of this category are delivered when set
+	Active     bool        `json:"active" example:"true"`
+	CreatedAt  time.Time   `json:"created_at" example:"2025-04-21T12:00:00Z"`
+	UpdatedAt  time.Time   `json:"updated_at" example:"2025-04-21T14:00:00Z"`
+}
+
+// Accepts reports whether the subscription wants the given event.
+func (s WebhookSubscription) Accepts(event Event) bool {
+	if !s.Active {
+		return false
+	}
+	if s.CategoryID != nil && *s.CategoryID != event.Complaint.Category.ID {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id" example:"9a1d2c3b-4e5f-6a7b-8c9d-0e1f2a3b4c5d"`
+	SubscriptionID uuid.UUID       `json:"subscription_id" example:"0e4c6f1a-7d8b-4c1e-9a55-3b2f1d0c9e88"`
+	EventID        uuid.UUID       `json:"event_id" example:"5b0f6a4e-2f4e-4a8e-9b1a-0c6f7b2d9e11"`
+	EventType      EventType       `json:"event_type" example:"complaint.created"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         DeliveryStatus  `json:"status" example:"pending"`
+	Attempts       int             `json:"attempts" example:"1"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" example:"2025-04-21T12:00:30Z"`
+	LastError      sql.NullString  `json:"last_error" swaggertype:"string" example:"unexpected status 503"`
+	ResponseCode   sql.NullInt32   `json:"response_code" swaggertype:"integer" example:"503"`
+	CreatedAt      time.Time       `json:"created_at" example:"2025-04-21T12:00:00Z"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at" swaggertype:"string" example:"2025-04-21T12:00:01Z"`
+}
@@ -4,25 +4,133 @@ import (
 	"complaint_server/internal/domain"
 	"context"
 	"github.com/google/uuid"
+	"time"
 )
 
 type CategoryRepository interface {
 	Create(ctx context.Context, category domain.Category) (uuid.UUID, error)
 	GetAll(ctx context.Context) ([]domain.Category, error)
 	GetByID(ctx context.Context, id uuid.UUID) (domain.Category, error)
-	Update(ctx context.Context, id uuid.UUID, category domain.Category) (uuid.UUID, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Update and Delete fail with ErrVersionMismatch unless expectedVersion is 0 or the current version.
+	Update(ctx context.Context, id uuid.UUID, category domain.Category, expectedVersion int) (uuid.UUID, error)
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion int) error
+	// Patch writes only the fields of the patch that differ from the stored category.
+	Patch(ctx context.Context, id uuid.UUID, patch domain.CategoryPatch, expectedVersion int) (domain.Category, error)
+	GetAllIncludingArchived(ctx context.Context) ([]domain.Category, error)
+	// Import upserts the entries by title in one transaction. Nothing is written when dryRun is set.
+	Import(ctx context.Context, entries []domain.CategoryEntry, archiveMissing bool, dryRun bool) (domain.CategoryImportResult, error)
+	// GetVersions returns the content history of a category, newest first.
+	GetVersions(ctx context.Context, id uuid.UUID) ([]domain.CategoryVersion, error)
 }
 
 type ComplaintRepository interface {
-	SaveComplaint(ctx context.Context, barcode int, categoryID uuid.UUID, message string) (uuid.UUID, string, error)
+	SaveComplaint(ctx context.Context, barcode int, categoryID uuid.UUID, message string, review domain.ContentReview) (uuid.UUID, string, error)
 	IsOwnerOfComplaint(ctx context.Context, id uuid.UUID, barcode int) (bool, error)
 	GetComplaintByUUID(ctx context.Context, id uuid.UUID) (domain.Complaint, error)
-	GetComplaints(ctx context.Context) ([]domain.Complaint, error)
-	GetComplaintsByCategoryId(ctx context.Context, categoryId uuid.UUID) ([]domain.Complaint, error)
+	GetComplaints(ctx context.Context, order domain.ComplaintOrder) ([]domain.Complaint, error)
+	GetComplaintsByCategoryId(ctx context.Context, categoryId uuid.UUID, order domain.ComplaintOrder) ([]domain.Complaint, error)
 	GetComplaintsByBarcode(ctx context.Context, barcode int) ([]domain.Complaint, error)
 	CheckComplaintLimit(ctx context.Context, barcode int) (bool, error)
-	UpdateComplaintStatus(ctx context.Context, id uuid.UUID, status domain.ComplaintStatus, answer string) error
-	DeleteComplaint(ctx context.Context, id uuid.UUID) error
-	UpdateComplaint(ctx context.Context, id uuid.UUID, complaint domain.Complaint) (domain.Complaint, error)
+	// DeleteComplaint and UpdateComplaint fail with ErrVersionMismatch unless expectedVersion
+	// is 0 or the current version.
+	DeleteComplaint(ctx context.Context, id uuid.UUID, expectedVersion int) error
+	UpdateComplaint(ctx context.Context, id uuid.UUID, complaint domain.Complaint, actor int, expectedVersion int) (domain.Complaint, error)
+	// PatchComplaint writes only the fields of the patch that differ from the stored complaint.
+	// A status change must follow domain.CheckTransition.
+	PatchComplaint(ctx context.Context, id uuid.UUID, patch domain.ComplaintPatch, actor int, expectedVersion int) (domain.Complaint, error)
+	// ExportComplaints calls fn for every matching complaint, reading them through a server-side cursor.
+	ExportComplaints(ctx context.Context, filter domain.ComplaintFilter, batchSize int, fn func(domain.Complaint) error) error
+	CountComplaints(ctx context.Context, filter domain.ComplaintFilter) (int64, error)
+	// BulkUpdateStatus applies the update in one transaction. It fails with ErrBulkTooLarge
+	// when more than limit complaints are selected.
+	BulkUpdateStatus(ctx context.Context, update domain.BulkStatusUpdate, limit int) (domain.BulkResult, error)
+	FindSimilar(ctx context.Context, id uuid.UUID, opts domain.SimilarityOptions) ([]domain.SimilarComplaint, error)
+	// CountSimilarToText counts complaints of the category created within the window that resemble message.
+	CountSimilarToText(ctx context.Context, categoryID uuid.UUID, message string, opts domain.SimilarityOptions) (int, error)
+	// Merge marks the duplicates as merged into the primary complaint and copies its status and answer to them.
+	Merge(ctx context.Context, primaryID uuid.UUID, duplicateIDs []uuid.UUID, actor int) (domain.BulkResult, error)
+	// SetEndorsement adds or removes the student's endorsement. Endorsing twice or removing a missing one is a no-op.
+	SetEndorsement(ctx context.Context, id uuid.UUID, barcode int, endorse bool) (domain.Endorsement, error)
+	// GetBoard lists resolved, unmerged complaints of public categories that aren't hidden from the board.
+	GetBoard(ctx context.Context, filter domain.BoardFilter) ([]domain.BoardComplaint, int64, error)
+	SetHiddenFromBoard(ctx context.Context, id uuid.UUID, hidden bool, expectedVersion int) (int, error)
+	// ReadOriginal returns the sealed original message and records the read by actor in the audit log.
+	ReadOriginal(ctx context.Context, id uuid.UUID, actor int) ([]byte, []string, error)
+	// RateComplaint stores the owner's rating of a complaint resolved no longer than window ago.
+	// A rating that says the problem isn't resolved reopens the complaint.
+	RateComplaint(ctx context.Context, rating domain.Rating, barcode int, window time.Duration) (domain.Rating, error)
+	// EditComplaint applies the owner's edit while the complaint is pending and unanswered and
+	// stores the result as a new revision. review applies to edit.Message.
+	EditComplaint(ctx context.Context, id uuid.UUID, barcode int, edit domain.ComplaintEdit, review domain.ContentReview) (domain.Complaint, error)
+	// GetRevisions returns the revisions of a complaint, oldest first. A complaint that was never
+	// edited has one revision with its current content.
+	GetRevisions(ctx context.Context, id uuid.UUID) ([]domain.ComplaintRevision, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (uuid.UUID, error)
+	GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, id uuid.UUID) (domain.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id uuid.UUID, subscription domain.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (uuid.UUID, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, attempts int, responseCode int) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, responseCode int, lastError string, retryIn time.Duration, status domain.DeliveryStatus) error
+	GetDeliveries(ctx context.Context, subscriptionID *uuid.UUID, status domain.DeliveryStatus, limit int) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id uuid.UUID) error
+}
+
+type OutboxRepository interface {
+	ClaimBatch(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, attempts int, lastError string, retryIn time.Duration) error
+	Stats(ctx context.Context) (domain.OutboxStats, error)
+	DeletePublishedBefore(ctx context.Context, age time.Duration) (int64, error)
+}
+
+type StudentRepository interface {
+	GetContact(ctx context.Context, barcode int) (domain.StudentContact, error)
+}
+
+// NotificationRepository records which events a student was already notified of, across replicas
+// and restarts. ClaimSend reports false if the event was claimed before.
+type NotificationRepository interface {
+	ClaimSend(ctx context.Context, eventID uuid.UUID) (bool, error)
+	ReleaseSend(ctx context.Context, eventID uuid.UUID) error
+}
+
+type StatsRepository interface {
+	GetStats(ctx context.Context, filter domain.StatsFilter) (domain.ComplaintStats, error)
+	Refresh(ctx context.Context) (bool, error)
+}
+
+type SuggestRepository interface {
+	TrainingSamples(ctx context.Context, since time.Time, limit int) ([]domain.TrainingSample, error)
+	KeywordRules(ctx context.Context) ([]domain.KeywordRule, error)
+	SetKeywords(ctx context.Context, categoryID uuid.UUID, keywords []string) error
+	RecordSuggestion(ctx context.Context, complaintID uuid.UUID, categoryID uuid.UUID) error
+	Accuracy(ctx context.Context, from *time.Time, to *time.Time) (domain.SuggestionAccuracy, error)
+}
+
+type AnswerTemplateRepository interface {
+	Create(ctx context.Context, template domain.AnswerTemplate) (uuid.UUID, error)
+	// GetAll returns the global templates and, when categoryID is set, the templates of that category.
+	GetAll(ctx context.Context, categoryID *uuid.UUID) ([]domain.AnswerTemplate, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.AnswerTemplate, error)
+	Update(ctx context.Context, id uuid.UUID, template domain.AnswerTemplate) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type ExportJobRepository interface {
+	Create(ctx context.Context, job domain.ExportJob) (uuid.UUID, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.ExportJob, error)
+	// ClaimNext marks the oldest queued job, or a running job whose lease expired, as running.
+	// It returns ErrExportJobNotFound when there is nothing to do.
+	ClaimNext(ctx context.Context, lease time.Duration) (domain.ExportJob, error)
+	UpdateProgress(ctx context.Context, id uuid.UUID, total int64, rows int64, lease time.Duration) error
+	MarkDone(ctx context.Context, id uuid.UUID, filePath string, fileSize int64, rows int64) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error
+	// DeleteFinishedOlderThan removes jobs that finished more than age ago and returns their file paths.
+	DeleteFinishedOlderThan(ctx context.Context, age time.Duration) ([]string, error)
 }
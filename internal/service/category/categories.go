@@ -2,8 +2,6 @@ package serviceCategory
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"time"
 
 	"complaint_server/internal/domain"
@@ -13,7 +11,11 @@ import (
 	"github.com/google/uuid"
 )
 
-const cacheKey = "cache:/categories"
+const (
+	cacheKey      = "cache:/categories"
+	categoryKey   = "cache:category:"
+	categoriesTTL = 5 * time.Minute
+)
 
 type CategoryService struct {
 	repo  repository.CategoryRepository
@@ -24,55 +26,71 @@ func NewCategoriesService(repo repository.CategoryRepository, cache storage.Cach
 	return &CategoryService{repo: repo, cache: cache}
 }
 
+// invalidate drops cached results after a write, including HTTP responses tagged with categories.
+func (s *CategoryService) invalidate(ctx context.Context, ids ...uuid.UUID) {
+	_ = s.cache.Delete(ctx, cacheKey)
+	for _, id := range ids {
+		_ = s.cache.Delete(ctx, categoryKey+id.String())
+	}
+	_ = s.cache.Invalidate(ctx, storage.TagCategories)
+}
+
+// invalidateBoard drops the cached public board pages after a write that changes which
+// categories the board shows.
+func (s *CategoryService) invalidateBoard(ctx context.Context) {
+	_ = s.cache.Set(ctx, storage.BoardGenerationKey, time.Now().UnixNano(), 0)
+}
+
 func (s *CategoryService) CreateCategory(ctx context.Context, category domain.Category) (uuid.UUID, error) {
 	id, err := s.repo.Create(ctx, category)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx)
 	}
 	return id, err
 }
 
-func (s *CategoryService) UpdateCategory(ctx context.Context, id uuid.UUID, category domain.Category) (uuid.UUID, error) {
-	updatedID, err := s.repo.Update(ctx, id, category)
+// UpdateCategory overwrites the category if it is still at expectedVersion; 0 skips the check.
+// The overwrite always sets the public flag, so the board is dropped too.
+func (s *CategoryService) UpdateCategory(ctx context.Context, id uuid.UUID, category domain.Category, expectedVersion int) (uuid.UUID, error) {
+	updatedID, err := s.repo.Update(ctx, id, category, expectedVersion)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx, id)
+		s.invalidateBoard(ctx)
 	}
 	return updatedID, err
 }
 
-func (s *CategoryService) GetCategoryById(ctx context.Context, categoryId uuid.UUID) (domain.Category, error) {
-	return s.repo.GetByID(ctx, categoryId)
-}
-
-func (s *CategoryService) GetCategories(ctx context.Context) ([]domain.Category, error) {
-	cached, err := s.cache.Get(ctx, cacheKey)
-	if err == nil && len(cached) > 0 {
-		var categories []domain.Category
-		if err := json.Unmarshal([]byte(cached), &categories); err == nil {
-			return categories, nil
-		}
-	}
-
-	categories, err := s.repo.GetAll(ctx)
-	if err != nil {
-		if errors.Is(err, storage.ErrCategoryNotFound) {
-			return nil, err
+// PatchCategory applies a partial update if the category is still at expectedVersion.
+func (s *CategoryService) PatchCategory(ctx context.Context, id uuid.UUID, patch domain.CategoryPatch, expectedVersion int) (domain.Category, error) {
+	category, err := s.repo.Patch(ctx, id, patch, expectedVersion)
+	if err == nil {
+		s.invalidate(ctx, id)
+		if patch.Public != nil {
+			s.invalidateBoard(ctx)
 		}
-		return nil, err
 	}
+	return category, err
+}
 
-	data, err := json.Marshal(categories)
-	if err == nil {
-		_ = s.cache.Set(ctx, cacheKey, data, 5*time.Minute)
-	}
+func (s *CategoryService) GetCategoryById(ctx context.Context, categoryId uuid.UUID) (domain.Category, error) {
+	return storage.Fetch(ctx, s.cache, categoryKey+categoryId.String(), categoriesTTL, func(ctx context.Context) (domain.Category, error) {
+		return s.repo.GetByID(ctx, categoryId)
+	})
+}
 
-	return categories, nil
+func (s *CategoryService) GetCategories(ctx context.Context) ([]domain.Category, error) {
+	return storage.Fetch(ctx, s.cache, cacheKey, categoriesTTL, s.repo.GetAll)
 }
 
-func (s *CategoryService) DeleteCategoryById(ctx context.Context, categoryID uuid.UUID) error {
-	err := s.repo.Delete(ctx, categoryID)
+func (s *CategoryService) DeleteCategoryById(ctx context.Context, categoryID uuid.UUID, expectedVersion int) error {
+	err := s.repo.Delete(ctx, categoryID, expectedVersion)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx, categoryID)
 	}
 	return err
 }
+
+// GetVersions returns the content history of a category, newest first.
+func (s *CategoryService) GetVersions(ctx context.Context, categoryID uuid.UUID) ([]domain.CategoryVersion, error) {
+	return s.repo.GetVersions(ctx, categoryID)
+}
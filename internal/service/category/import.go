@@ -0,0 +1,118 @@
+package serviceCategory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"complaint_server/internal/domain"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidDocument = errors.New("invalid category document")
+
+// DecodeDocument parses a category document. format is "json" or "yaml"; JSON is accepted
+// as YAML too. Both a {categories: [...]} document and a bare list of categories are accepted.
+func DecodeDocument(data []byte, format string) (domain.CategoryDocument, error) {
+	unmarshal := yaml.Unmarshal
+	if format == "json" {
+		unmarshal = json.Unmarshal
+	}
+
+	var doc domain.CategoryDocument
+	if err := unmarshal(data, &doc); err == nil {
+		return doc, nil
+	}
+	if err := unmarshal(data, &doc.Categories); err != nil {
+		return domain.CategoryDocument{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return doc, nil
+}
+
+// EncodeDocument renders categories as a document that Import accepts back.
+func EncodeDocument(categories []domain.Category, format string) ([]byte, error) {
+	doc := domain.CategoryDocument{Categories: make([]domain.CategoryEntry, 0, len(categories))}
+	for _, category := range categories {
+		doc.Categories = append(doc.Categories, domain.CategoryEntry{
+			Title:       category.Title,
+			Description: category.Description,
+			Answer:      category.Answer,
+			Archived:    category.Archived,
+		})
+	}
+	if format == "json" {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return yaml.Marshal(doc)
+}
+
+func (s *CategoryService) ExportCategories(ctx context.Context, includeArchived bool) ([]domain.Category, error) {
+	if includeArchived {
+		return s.repo.GetAllIncludingArchived(ctx)
+	}
+	return s.repo.GetAll(ctx)
+}
+
+// ImportCategories validates the document and upserts its categories by title.
+// When a row is invalid nothing is written and the result lists the row errors.
+func (s *CategoryService) ImportCategories(ctx context.Context, doc domain.CategoryDocument, archiveMissing bool, dryRun bool) (domain.CategoryImportResult, error) {
+	if rowErrors := validateEntries(doc.Categories); len(rowErrors) > 0 {
+		return domain.CategoryImportResult{DryRun: dryRun, Errors: rowErrors}, nil
+	}
+
+	result, err := s.repo.Import(ctx, doc.Categories, archiveMissing, dryRun)
+	if err != nil {
+		return domain.CategoryImportResult{}, err
+	}
+	if result.Applied {
+		var ids []uuid.UUID
+		board := false
+		for _, change := range result.Changes {
+			if change.ID != nil {
+				ids = append(ids, *change.ID)
+			}
+			// An update may bring an archived category back.
+			board = board || change.Action == domain.ImportArchive || change.Action == domain.ImportUpdate
+		}
+		s.invalidate(ctx, ids...)
+		if board {
+			s.invalidateBoard(ctx)
+		}
+	}
+	return result, nil
+}
+
+func validateEntries(entries []domain.CategoryEntry) []domain.ImportRowError {
+	var rowErrors []domain.ImportRowError
+	rows := make(map[string]int, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		entry.Title = strings.TrimSpace(entry.Title)
+		entry.Description = strings.TrimSpace(entry.Description)
+		entry.Answer = strings.TrimSpace(entry.Answer)
+
+		fail := func(message string) {
+			rowErrors = append(rowErrors, domain.ImportRowError{Row: i + 1, Title: entry.Title, Message: message})
+		}
+		switch {
+		case entry.Title == "":
+			fail("title is required")
+		case entry.Description == "":
+			fail("description is required")
+		case entry.Answer == "":
+			fail("answer is required")
+		}
+
+		key := strings.ToLower(entry.Title)
+		if first, ok := rows[key]; ok && entry.Title != "" {
+			fail(fmt.Sprintf("duplicate title, first used in row %d", first))
+			continue
+		}
+		rows[key] = i + 1
+	}
+	return rowErrors
+}
@@ -2,13 +2,16 @@ package serviceComplaint
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/cipher"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"complaint_server/internal/config"
 	"complaint_server/internal/domain"
 	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/redact"
 	"complaint_server/internal/storage"
 
 	"github.com/google/uuid"
@@ -16,15 +19,77 @@ import (
 
 const cacheKey = "cache:/complaints"
 
+// listOrders are the orders the complaint list is cached in.
+var listOrders = []domain.ComplaintOrder{domain.OrderDefault, domain.OrderEndorsements}
+
+func listCacheKey(order domain.ComplaintOrder) string {
+	if order == domain.OrderDefault {
+		return cacheKey
+	}
+	return cacheKey + "?sort=" + string(order)
+}
+
+// boardCacheKey prefixes cached public board pages, which expire after boardCacheTTL. The
+// current generation under storage.BoardGenerationKey is part of every page key.
+const (
+	boardCacheKey = "cache:/public/complaints:"
+	boardCacheTTL = time.Minute
+)
+
+// BulkLimit is the most complaints one bulk update may change.
+const BulkLimit = 500
+
+// ComplaintService changes complaints through the repository, which records the matching
+// lifecycle events in the outbox within the same transaction.
 type ComplaintService struct {
-	repo  repository.ComplaintRepository
-	cache storage.Cache
+	repo       repository.ComplaintRepository
+	cache      storage.Cache
+	similarity domain.SimilarityOptions
+	content    *redact.Pipeline
+	// profanity masks words from the profanity lists on the public board, whatever
+	// CONTENT_PROFANITY_MODE says: a flagged message is kept as written for moderators.
+	profanity redact.Detector
+	sealer    cipher.AEAD
+	auditors  map[int]bool
+	// ratingWindow is how long after resolution the owner can rate a complaint.
+	ratingWindow time.Duration
 }
 
-func NewComplaintsService(repo repository.ComplaintRepository, cache storage.Cache) *ComplaintService {
-	return &ComplaintService{repo: repo, cache: cache}
+func NewComplaintsService(repo repository.ComplaintRepository, cache storage.Cache, cfg *config.Config, log *slog.Logger) *ComplaintService {
+	auditors := make(map[int]bool, len(cfg.Content.Auditors))
+	for _, barcode := range cfg.Content.Auditors {
+		auditors[barcode] = true
+	}
+
+	profanity := newProfanity(cfg.Content, log)
+
+	return &ComplaintService{
+		repo:  repo,
+		cache: cache,
+		similarity: domain.SimilarityOptions{
+			Threshold: cfg.Similarity.Threshold,
+			Window:    config.ParseDuration(log, "SIMILARITY_WINDOW", cfg.Similarity.Window, 30*24*time.Hour),
+			Limit:     cfg.Similarity.Limit,
+			FullText:  cfg.Similarity.FullText,
+		},
+		content:      newContentPipeline(cfg.Content, profanity, log),
+		profanity:    profanity,
+		sealer:       newSealer(cfg.Content.OriginalKey, log),
+		auditors:     auditors,
+		ratingWindow: config.ParseDuration(log, "RATING_WINDOW", cfg.Rating.Window, 7*24*time.Hour),
+	}
+}
+
+// invalidate drops cached results after a write, including HTTP responses tagged with complaints.
+func (s *ComplaintService) invalidate(ctx context.Context) {
+	for _, order := range listOrders {
+		_ = s.cache.Delete(ctx, listCacheKey(order))
+	}
+	_ = s.cache.Invalidate(ctx, storage.TagComplaints)
+	_ = s.cache.Set(ctx, storage.BoardGenerationKey, time.Now().UnixNano(), 0)
 }
 
+// CreateComplaint runs the message through the content pipeline before saving it.
 func (s *ComplaintService) CreateComplaint(ctx context.Context, barcode int, categoryID uuid.UUID, message string) (uuid.UUID, string, error) {
 	canSubmit, err := s.repo.CheckComplaintLimit(ctx, barcode)
 	if err != nil {
@@ -34,46 +99,32 @@ func (s *ComplaintService) CreateComplaint(ctx context.Context, barcode int, cat
 		return uuid.Nil, "", storage.ErrLimitOneComplaintInOneHour
 	}
 
-	complaintID, answer, err := s.repo.SaveComplaint(ctx, barcode, categoryID, message)
+	message, review, err := s.reviewMessage(message)
 	if err != nil {
-		return uuid.Nil, "", fmt.Errorf("failed to save complaint: %w", err)
-	}
-
-	_ = s.cache.Delete(ctx, cacheKey)
-	return complaintID, answer, nil
-}
-
-func (s *ComplaintService) GetAllComplaints(ctx context.Context) ([]domain.Complaint, error) {
-	cachedData, err := s.cache.Get(ctx, cacheKey)
-	if err == nil && len(cachedData) > 0 {
-		var cachedComplaints []domain.Complaint
-		if err := json.Unmarshal([]byte(cachedData), &cachedComplaints); err == nil {
-			return cachedComplaints, nil
-		}
+		return uuid.Nil, "", err
 	}
 
-	complaints, err := s.repo.GetComplaints(ctx)
+	complaintID, answer, err := s.repo.SaveComplaint(ctx, barcode, categoryID, message, review)
 	if err != nil {
-		if errors.Is(err, storage.ErrComplaintNotFound) {
-			return nil, storage.ErrComplaintNotFound
-		}
-		return nil, err
+		return uuid.Nil, "", fmt.Errorf("failed to save complaint: %w", err)
 	}
 
-	data, err := json.Marshal(complaints)
-	if err == nil {
-		_ = s.cache.Set(ctx, cacheKey, data, 5*time.Minute)
-	}
+	s.invalidate(ctx)
+	return complaintID, answer, nil
+}
 
-	return complaints, nil
+func (s *ComplaintService) GetAllComplaints(ctx context.Context, order domain.ComplaintOrder) ([]domain.Complaint, error) {
+	return storage.Fetch(ctx, s.cache, listCacheKey(order), 5*time.Minute, func(ctx context.Context) ([]domain.Complaint, error) {
+		return s.repo.GetComplaints(ctx, order)
+	})
 }
 
 func (s *ComplaintService) GetComplaintByUUID(ctx context.Context, complaintID uuid.UUID) (domain.Complaint, error) {
 	return s.repo.GetComplaintByUUID(ctx, complaintID)
 }
 
-func (s *ComplaintService) GetComplaintsByCategoryId(ctx context.Context, categoryID uuid.UUID) ([]domain.Complaint, error) {
-	return s.repo.GetComplaintsByCategoryId(ctx, categoryID)
+func (s *ComplaintService) GetComplaintsByCategoryId(ctx context.Context, categoryID uuid.UUID, order domain.ComplaintOrder) ([]domain.Complaint, error) {
+	return s.repo.GetComplaintsByCategoryId(ctx, categoryID, order)
 }
 
 func (s *ComplaintService) GetComplaintsByBarcode(ctx context.Context, barcode int) ([]domain.Complaint, error) {
@@ -87,26 +138,28 @@ func (s *ComplaintService) GetComplaintsByBarcode(ctx context.Context, barcode i
 	return complaints, nil
 }
 
-func (s *ComplaintService) UpdateComplaintStatus(ctx context.Context, complaintID uuid.UUID, status domain.ComplaintStatus, answer string) error {
-	err := s.repo.UpdateComplaintStatus(ctx, complaintID, status, answer)
+// UpdateComplaint overwrites the complaint if it is still at expectedVersion; 0 skips the check.
+func (s *ComplaintService) UpdateComplaint(ctx context.Context, complaintID uuid.UUID, complaint domain.Complaint, actor int, expectedVersion int) (domain.Complaint, error) {
+	updatedComplaint, err := s.repo.UpdateComplaint(ctx, complaintID, complaint, actor, expectedVersion)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx)
 	}
-	return err
+	return updatedComplaint, err
 }
 
-func (s *ComplaintService) UpdateComplaint(ctx context.Context, complaintID uuid.UUID, complaint domain.Complaint) (domain.Complaint, error) {
-	updatedComplaint, err := s.repo.UpdateComplaint(ctx, complaintID, complaint)
+// PatchComplaint applies an admin's partial update if the complaint is still at expectedVersion.
+func (s *ComplaintService) PatchComplaint(ctx context.Context, complaintID uuid.UUID, patch domain.ComplaintPatch, actor int, expectedVersion int) (domain.Complaint, error) {
+	complaint, err := s.repo.PatchComplaint(ctx, complaintID, patch, actor, expectedVersion)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx)
 	}
-	return updatedComplaint, err
+	return complaint, err
 }
 
-func (s *ComplaintService) DeleteComplaintById(ctx context.Context, complaintID uuid.UUID) error {
-	err := s.repo.DeleteComplaint(ctx, complaintID)
+func (s *ComplaintService) DeleteComplaintById(ctx context.Context, complaintID uuid.UUID, expectedVersion int) error {
+	err := s.repo.DeleteComplaint(ctx, complaintID, expectedVersion)
 	if err == nil {
-		_ = s.cache.Delete(ctx, cacheKey)
+		s.invalidate(ctx)
 	}
 	return err
 }
@@ -118,3 +171,107 @@ func (s *ComplaintService) CanSubmitByBarcode(ctx context.Context, barcode int)
 func (s *ComplaintService) CanUserDeleteComplaintById(ctx context.Context, complaintID uuid.UUID, barcode int) (bool, error) {
 	return s.repo.IsOwnerOfComplaint(ctx, complaintID, barcode)
 }
+
+// BulkUpdateStatus changes the status of many complaints at once. Complaints whose
+// lifecycle doesn't allow the change are skipped and reported, the rest are updated together.
+func (s *ComplaintService) BulkUpdateStatus(ctx context.Context, update domain.BulkStatusUpdate) (domain.BulkResult, error) {
+	seen := make(map[uuid.UUID]bool, len(update.IDs))
+	ids := update.IDs[:0:0]
+	for _, id := range update.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	update.IDs = ids
+
+	result, err := s.repo.BulkUpdateStatus(ctx, update, BulkLimit)
+	if err == nil && result.Updated > 0 {
+		s.invalidate(ctx)
+	}
+	return result, err
+}
+
+// FindSimilar lists complaints of the same category and time window that look like the given one.
+func (s *ComplaintService) FindSimilar(ctx context.Context, id uuid.UUID) ([]domain.SimilarComplaint, error) {
+	return s.repo.FindSimilar(ctx, id, s.similarity)
+}
+
+// DuplicateHint checks whether a complaint about to be submitted resembles recent ones.
+func (s *ComplaintService) DuplicateHint(ctx context.Context, categoryID uuid.UUID, message string) (domain.DuplicateHint, error) {
+	count, err := s.repo.CountSimilarToText(ctx, categoryID, message, s.similarity)
+	if err != nil {
+		return domain.DuplicateHint{}, err
+	}
+	return domain.DuplicateHint{PossiblyAlreadyReported: count > 0, SimilarComplaints: count}, nil
+}
+
+// Merge makes the duplicates follow the primary complaint's status and answer from now on.
+func (s *ComplaintService) Merge(ctx context.Context, primaryID uuid.UUID, duplicateIDs []uuid.UUID, actor int) (domain.BulkResult, error) {
+	seen := make(map[uuid.UUID]bool, len(duplicateIDs))
+	ids := duplicateIDs[:0:0]
+	for _, id := range duplicateIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	result, err := s.repo.Merge(ctx, primaryID, ids, actor)
+	if err == nil && result.Updated > 0 {
+		s.invalidate(ctx)
+	}
+	return result, err
+}
+
+func (s *ComplaintService) EndorseComplaint(ctx context.Context, id uuid.UUID, barcode int, endorse bool) (domain.Endorsement, error) {
+	endorsement, err := s.repo.SetEndorsement(ctx, id, barcode, endorse)
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return endorsement, err
+}
+
+// RateComplaint records the owner's rating. A rating that says the problem isn't resolved
+// reopens the complaint, so the cached list is dropped in that case.
+func (s *ComplaintService) RateComplaint(ctx context.Context, rating domain.Rating, barcode int) (domain.Rating, error) {
+	rating, err := s.repo.RateComplaint(ctx, rating, barcode, s.ratingWindow)
+	if err == nil && rating.Reopened {
+		s.invalidate(ctx)
+	}
+	return rating, err
+}
+
+// GetBoard returns a page of the public board with profanity and personal data masked in the
+// messages. Pages are cached briefly because the endpoint is public.
+func (s *ComplaintService) GetBoard(ctx context.Context, filter domain.BoardFilter) (domain.BoardPage, error) {
+	generation, _ := s.cache.Get(ctx, storage.BoardGenerationKey) // Empty until the first write
+	key := fmt.Sprintf("%s%s:%d:%d", boardCacheKey, generation, filter.Page, filter.PageSize)
+	if filter.CategoryID != nil {
+		key += ":" + filter.CategoryID.String()
+	}
+	return storage.Fetch(ctx, s.cache, key, boardCacheTTL, func(ctx context.Context) (domain.BoardPage, error) {
+		items, total, err := s.repo.GetBoard(ctx, filter)
+		if err != nil {
+			return domain.BoardPage{}, err
+		}
+		for i := range items {
+			items[i].Message = redact.PII(s.maskProfanity(items[i].Message))
+			items[i].Answer = redact.PII(s.maskProfanity(items[i].Answer))
+		}
+		return domain.BoardPage{Items: items, Page: filter.Page, PageSize: filter.PageSize, Total: total}, nil
+	})
+}
+
+func (s *ComplaintService) maskProfanity(text string) string {
+	masked, _ := s.profanity.Mask(text)
+	return masked
+}
+
+func (s *ComplaintService) SetHiddenFromBoard(ctx context.Context, id uuid.UUID, hidden bool, expectedVersion int) (int, error) {
+	version, err := s.repo.SetHiddenFromBoard(ctx, id, hidden, expectedVersion)
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return version, err
+}
@@ -0,0 +1,145 @@
+package serviceComplaint
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/shared/redact"
+	"complaint_server/internal/storage"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotAuditor is returned when an admin outside CONTENT_AUDITORS asks for an original text.
+var ErrNotAuditor = errors.New("only auditors can read original complaint texts")
+
+// ErrOriginalsDisabled is returned for original texts while CONTENT_ORIGINAL_KEY is not set.
+var ErrOriginalsDisabled = fmt.Errorf("%w: CONTENT_ORIGINAL_KEY is not set", storage.ErrNoOriginal)
+
+// newProfanity loads the word lists named in cfg. Missing lists are skipped, so a bad setting
+// never stops the server.
+func newProfanity(cfg config.Content, log *slog.Logger) redact.Detector {
+	var words []string
+	for _, lang := range cfg.ProfanityLangs {
+		list, err := redact.Words(lang)
+		if err != nil {
+			log.Error("invalid CONTENT_PROFANITY_LANGS", sl.Err(err))
+			continue
+		}
+		words = append(words, list...)
+	}
+	if cfg.ProfanityFile != "" {
+		list, err := redact.LoadWords(cfg.ProfanityFile)
+		if err != nil {
+			log.Error("failed to read CONTENT_PROFANITY_FILE", sl.Err(err))
+		}
+		words = append(words, list...)
+	}
+	return redact.NewWordList("profanity", words)
+}
+
+// newContentPipeline builds the PII and profanity processors from cfg. Invalid modes fall back
+// to the defaults, so a bad setting never stops the server.
+func newContentPipeline(cfg config.Content, profanity redact.Detector, log *slog.Logger) *redact.Pipeline {
+	mode := func(name string, value string, def redact.Mode) redact.Mode {
+		m, err := redact.ParseMode(value)
+		if err != nil {
+			log.Error("invalid "+name, slog.String("value", value), sl.Err(err))
+			return def
+		}
+		return m
+	}
+
+	// Profanity runs first so that it sees the text before any placeholders are inserted.
+	return redact.NewPipeline(
+		redact.Processor{Detector: profanity, Mode: mode("CONTENT_PROFANITY_MODE", cfg.ProfanityMode, redact.ModeFlag)},
+		redact.Processor{Detector: redact.EmailDetector(), Mode: mode("CONTENT_EMAIL_MODE", cfg.EmailMode, redact.ModeMask)},
+		redact.Processor{Detector: redact.IINDetector(), Mode: mode("CONTENT_IIN_MODE", cfg.IINMode, redact.ModeMask)},
+		redact.Processor{Detector: redact.PhoneDetector(), Mode: mode("CONTENT_PHONE_MODE", cfg.PhoneMode, redact.ModeMask)},
+	)
+}
+
+// newSealer returns the AES-GCM cipher for original texts, keyed by the SHA-256 of key. Without
+// a key it returns nil and originals of masked messages are not kept.
+func newSealer(key string, log *slog.Logger) cipher.AEAD {
+	if key == "" {
+		log.Warn("CONTENT_ORIGINAL_KEY is not set, original texts of masked complaints will not be kept")
+		return nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		panic(err) // a 32-byte key is always valid
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+// seal encrypts text with a random nonce, which is stored in front of the ciphertext.
+func (s *ComplaintService) seal(text string) ([]byte, error) {
+	nonce := make([]byte, s.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.sealer.Seal(nonce, nonce, []byte(text), nil), nil
+}
+
+func (s *ComplaintService) open(sealed []byte) (string, error) {
+	size := s.sealer.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("sealed text is too short")
+	}
+	text, err := s.sealer.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// reviewMessage runs the content pipeline over a new message. It returns the text to store
+// and the review to save with it, or a *redact.RejectedError.
+func (s *ComplaintService) reviewMessage(message string) (string, domain.ContentReview, error) {
+	result, err := s.content.Process(message)
+	if err != nil {
+		return "", domain.ContentReview{}, err
+	}
+	review := domain.ContentReview{Flags: result.Flagged}
+	if result.Changed() && s.sealer != nil {
+		review.SealedOriginal, err = s.seal(message)
+		if err != nil {
+			return "", domain.ContentReview{}, fmt.Errorf("failed to seal original text: %w", err)
+		}
+	}
+	return result.Text, review, nil
+}
+
+// ReadOriginal returns the message as submitted, before masking. Only admins listed in
+// CONTENT_AUDITORS may read it, and every read is written to the audit log.
+func (s *ComplaintService) ReadOriginal(ctx context.Context, id uuid.UUID, actor int) (domain.OriginalText, error) {
+	if !s.auditors[actor] {
+		return domain.OriginalText{}, ErrNotAuditor
+	}
+	if s.sealer == nil {
+		return domain.OriginalText{}, ErrOriginalsDisabled
+	}
+	sealed, flags, err := s.repo.ReadOriginal(ctx, id, actor)
+	if err != nil {
+		return domain.OriginalText{}, err
+	}
+	message, err := s.open(sealed)
+	if err != nil {
+		return domain.OriginalText{}, fmt.Errorf("failed to open original text: %w", err)
+	}
+	return domain.OriginalText{ComplaintID: id, Message: message, ContentFlags: flags}, nil
+}
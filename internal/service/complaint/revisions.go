@@ -0,0 +1,123 @@
+package serviceComplaint
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"github.com/google/uuid"
+	"strings"
+)
+
+// maxDiffCells bounds the word diff table; longer messages are shown as replaced whole.
+const maxDiffCells = 1 << 20
+
+// EditComplaint runs an edited message through the content pipeline, like a new one.
+func (s *ComplaintService) EditComplaint(ctx context.Context, id uuid.UUID, barcode int, edit domain.ComplaintEdit) (domain.Complaint, error) {
+	var review domain.ContentReview
+	if edit.Message != nil {
+		message, r, err := s.reviewMessage(*edit.Message)
+		if err != nil {
+			return domain.Complaint{}, err
+		}
+		edit.Message, review = &message, r
+	}
+
+	complaint, err := s.repo.EditComplaint(ctx, id, barcode, edit, review)
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return complaint, err
+}
+
+// GetRevisions returns the revisions with the changes of each against the one before it.
+// Only the owner and admins may see them.
+func (s *ComplaintService) GetRevisions(ctx context.Context, id uuid.UUID, barcode int, admin bool) ([]domain.ComplaintRevision, error) {
+	if !admin {
+		owner, err := s.repo.IsOwnerOfComplaint(ctx, id, barcode)
+		if err != nil {
+			return nil, err
+		}
+		if !owner {
+			return nil, storage.ErrNotComplaintOwner
+		}
+	}
+
+	revisions, err := s.repo.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(revisions); i++ {
+		prev := revisions[i-1]
+		revisions[i].Changes = diffWords(prev.Message, revisions[i].Message)
+		revisions[i].CategoryChanged = prev.CategoryID != revisions[i].CategoryID
+	}
+	return revisions, nil
+}
+
+// diffWords compares two texts word by word through their longest common subsequence.
+// Runs of the same operation are joined with single spaces.
+func diffWords(a string, b string) []domain.TextChange {
+	from, to := strings.Fields(a), strings.Fields(b)
+	if len(from)*len(to) > maxDiffCells {
+		return compact([]domain.TextChange{
+			{Op: domain.DiffDelete, Text: strings.Join(from, " ")},
+			{Op: domain.DiffInsert, Text: strings.Join(to, " ")},
+		})
+	}
+
+	// lcs[i][j] is the common subsequence length of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []domain.TextChange
+	add := func(op domain.DiffOp, word string) {
+		if n := len(changes); n > 0 && changes[n-1].Op == op {
+			changes[n-1].Text += " " + word
+			return
+		}
+		changes = append(changes, domain.TextChange{Op: op, Text: word})
+	}
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			add(domain.DiffEqual, from[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(domain.DiffDelete, from[i])
+			i++
+		default:
+			add(domain.DiffInsert, to[j])
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		add(domain.DiffDelete, from[i])
+	}
+	for ; j < len(to); j++ {
+		add(domain.DiffInsert, to[j])
+	}
+	return changes
+}
+
+func compact(changes []domain.TextChange) []domain.TextChange {
+	out := changes[:0]
+	for _, change := range changes {
+		if change.Text != "" {
+			out = append(out, change)
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package serviceComplaint
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"complaint_server/internal/domain"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.TextChange
+	}{
+		{
+			name: "equal",
+			a:    "no hot water",
+			b:    "no  hot\nwater",
+			want: []domain.TextChange{{Op: domain.DiffEqual, Text: "no hot water"}},
+		},
+		{
+			name: "insert",
+			a:    "no hot water",
+			b:    "no hot water after 11pm",
+			want: []domain.TextChange{
+				{Op: domain.DiffEqual, Text: "no hot water"},
+				{Op: domain.DiffInsert, Text: "after 11pm"},
+			},
+		},
+		{
+			name: "replace",
+			a:    "no hot water in block C",
+			b:    "no hot water in block D",
+			want: []domain.TextChange{
+				{Op: domain.DiffEqual, Text: "no hot water in block"},
+				{Op: domain.DiffDelete, Text: "C"},
+				{Op: domain.DiffInsert, Text: "D"},
+			},
+		},
+		{
+			name: "delete",
+			a:    "the wifi is very slow",
+			b:    "the wifi is slow",
+			want: []domain.TextChange{
+				{Op: domain.DiffEqual, Text: "the wifi is"},
+				{Op: domain.DiffDelete, Text: "very"},
+				{Op: domain.DiffEqual, Text: "slow"},
+			},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "broken chair",
+			want: []domain.TextChange{{Op: domain.DiffInsert, Text: "broken chair"}},
+		},
+		{
+			name: "both empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffWords(tt.a, tt.b); !slices.Equal(got, tt.want) {
+				t.Errorf("diffWords = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffWordsTooLong(t *testing.T) {
+	// Past maxDiffCells the texts are shown as replaced whole.
+	a := strings.Repeat("a ", 1100)
+	b := strings.Repeat("b ", 1000)
+	got := diffWords(a, b)
+	want := []domain.TextChange{
+		{Op: domain.DiffDelete, Text: strings.TrimSpace(a)},
+		{Op: domain.DiffInsert, Text: strings.TrimSpace(b)},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("diffWords returned %d changes, want a delete and an insert", len(got))
+	}
+}
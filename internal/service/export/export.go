@@ -0,0 +1,251 @@
+package serviceExport
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Columns are the exportable fields, in their default order.
+var Columns = []string{"id", "barcode", "category_id", "category", "status", "message", "answer", "created_at", "updated_at"}
+
+var ErrInvalidParams = errors.New("invalid export parameters")
+
+const (
+	dateLayout       = "2006-01-02"
+	defaultBatchSize = 500
+)
+
+type Options struct {
+	Format   Format
+	Columns  []string // Columns by default
+	Location *time.Location
+	// Pseudonymize replaces barcodes with a keyed hash, stable across exports.
+	Pseudonymize bool
+	// Progress, if set, is called with the number of rows written after every flushed batch.
+	Progress func(rows int)
+}
+
+type Exporter struct {
+	repo         repository.ComplaintRepository
+	batchSize    int
+	pseudonymKey []byte
+}
+
+func NewExporter(repo repository.ComplaintRepository, cfg *config.Config) *Exporter {
+	batchSize := cfg.Export.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Exporter{repo: repo, batchSize: batchSize, pseudonymKey: []byte(cfg.Export.PseudonymKey)}
+}
+
+// ParseParams validates export parameters and turns them into a filter and output options.
+// Errors wrap ErrInvalidParams.
+func (e *Exporter) ParseParams(params domain.ExportParams) (domain.ComplaintFilter, Options, error) {
+	var filter domain.ComplaintFilter
+	opts := Options{Format: Format(params.Format), Pseudonymize: params.Pseudonymize}
+	if opts.Pseudonymize && len(e.pseudonymKey) == 0 {
+		return filter, opts, fmt.Errorf("%w: pseudonymize needs EXPORT_PSEUDONYM_KEY to be set", ErrInvalidParams)
+	}
+
+	switch opts.Format {
+	case "":
+		opts.Format = FormatCSV
+	case FormatCSV, FormatNDJSON:
+	default:
+		return filter, opts, fmt.Errorf("%w: format must be csv or ndjson", ErrInvalidParams)
+	}
+
+	opts.Columns = Columns
+	if len(params.Columns) > 0 {
+		opts.Columns = nil
+		for _, column := range params.Columns {
+			column = strings.TrimSpace(column)
+			if !slices.Contains(Columns, column) {
+				return filter, opts, fmt.Errorf("%w: unknown column %q", ErrInvalidParams, column)
+			}
+			opts.Columns = append(opts.Columns, column)
+		}
+	}
+
+	opts.Location = time.UTC
+	if params.Timezone != "" {
+		location, err := time.LoadLocation(params.Timezone)
+		if err != nil {
+			return filter, opts, fmt.Errorf("%w: unknown time zone %q", ErrInvalidParams, params.Timezone)
+		}
+		opts.Location = location
+	}
+
+	switch params.Status {
+	case "", "pending", "approved", "rejected":
+		filter.Status = params.Status
+	default:
+		return filter, opts, fmt.Errorf("%w: status must be pending, approved or rejected", ErrInvalidParams)
+	}
+	filter.CategoryID = params.CategoryID
+
+	if params.From != "" {
+		from, err := time.Parse(dateLayout, params.From)
+		if err != nil {
+			return filter, opts, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidParams)
+		}
+		filter.From = &from
+	}
+	if params.To != "" {
+		to, err := time.Parse(dateLayout, params.To)
+		if err != nil {
+			return filter, opts, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidParams)
+		}
+		// The range includes the whole last day.
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	return filter, opts, nil
+}
+
+// Export writes the matching complaints to w and returns how many were written.
+// Output is flushed after every batch, and w is flushed too if it is an http.Flusher,
+// so a client sees rows while the export is still running.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, filter domain.ComplaintFilter, opts Options) (int, error) {
+	if len(opts.Columns) == 0 {
+		opts.Columns = Columns
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	buf := bufio.NewWriter(w)
+	flush := func() error {
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}
+
+	var write func(domain.Complaint) error
+	switch opts.Format {
+	case FormatNDJSON:
+		encoder := json.NewEncoder(buf)
+		write = func(complaint domain.Complaint) error {
+			record := make(map[string]any, len(opts.Columns))
+			for _, column := range opts.Columns {
+				record[column] = e.value(complaint, column, opts)
+			}
+			return encoder.Encode(record)
+		}
+	default:
+		writer := csv.NewWriter(buf)
+		if err := writer.Write(opts.Columns); err != nil {
+			return 0, err
+		}
+		record := make([]string, len(opts.Columns))
+		write = func(complaint domain.Complaint) error {
+			for i, column := range opts.Columns {
+				record[i] = escapeFormula(fmt.Sprint(e.value(complaint, column, opts)))
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	count := 0
+	err := e.repo.ExportComplaints(ctx, filter, e.batchSize, func(complaint domain.Complaint) error {
+		if err := write(complaint); err != nil {
+			return err
+		}
+		count++
+		if count%e.batchSize != 0 {
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(count)
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, flush()
+}
+
+func (e *Exporter) value(complaint domain.Complaint, column string, opts Options) any {
+	switch column {
+	case "id":
+		return complaint.ID.String()
+	case "barcode":
+		if opts.Pseudonymize {
+			return e.pseudonym(complaint.Barcode)
+		}
+		return strconv.Itoa(complaint.Barcode)
+	case "category_id":
+		return complaint.Category.ID.String()
+	case "category":
+		return complaint.Category.Title
+	case "status":
+		return complaint.Status
+	case "message":
+		return complaint.Message
+	case "answer":
+		return complaint.Answer.String
+	case "created_at":
+		return complaint.CreatedAt.In(opts.Location).Format(time.RFC3339)
+	case "updated_at":
+		return complaint.UpdatedAt.In(opts.Location).Format(time.RFC3339)
+	}
+	return ""
+}
+
+// escapeFormula keeps spreadsheets from running a cell as a formula by prefixing it with a quote.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (e *Exporter) pseudonym(barcode int) string {
+	mac := hmac.New(sha256.New, e.pseudonymKey)
+	mac.Write([]byte(strconv.Itoa(barcode)))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
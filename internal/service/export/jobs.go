@@ -0,0 +1,289 @@
+package serviceExport
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLinkExpired      = errors.New("download link expired")
+	ErrInvalidSignature = errors.New("invalid download link signature")
+	ErrNotReady         = errors.New("export is not finished")
+)
+
+// JobService runs exports in the background: Create queues a job, Run claims and writes
+// queued jobs as gzip files to the artifact directory and deletes them after the retention period.
+type JobService struct {
+	repo       repository.ExportJobRepository
+	complaints repository.ComplaintRepository
+	exporter   *Exporter
+	log        *slog.Logger
+
+	dir          string
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	linkTTL      time.Duration
+	retention    time.Duration
+	signingKey   []byte
+}
+
+func NewJobService(repo repository.ExportJobRepository, complaints repository.ComplaintRepository, exporter *Exporter, cfg *config.Config, log *slog.Logger) *JobService {
+	key := cfg.Export.SigningKey
+	if key == "" {
+		key = cfg.JwtSecret
+	}
+	return &JobService{
+		repo:         repo,
+		complaints:   complaints,
+		exporter:     exporter,
+		log:          log.With(slog.String("component", "service/export")),
+		dir:          cfg.Export.Dir,
+		workers:      max(cfg.Export.Workers, 1),
+		pollInterval: config.ParseDuration(log, "EXPORT_POLL_INTERVAL", cfg.Export.PollInterval, 2*time.Second),
+		lease:        config.ParseDuration(log, "EXPORT_LEASE", cfg.Export.Lease, 2*time.Minute),
+		linkTTL:      config.ParseDuration(log, "EXPORT_LINK_TTL", cfg.Export.LinkTTL, 15*time.Minute),
+		retention:    config.ParseDuration(log, "EXPORT_RETENTION", cfg.Export.Retention, 72*time.Hour),
+		signingKey:   []byte(key),
+	}
+}
+
+func (s *JobService) Create(ctx context.Context, params domain.ExportParams, requestedBy int) (domain.ExportJob, error) {
+	if _, _, err := s.exporter.ParseParams(params); err != nil {
+		return domain.ExportJob{}, err
+	}
+	if params.Format == "" {
+		params.Format = string(FormatCSV)
+	}
+
+	id, err := s.repo.Create(ctx, domain.ExportJob{Params: params, RequestedBy: requestedBy})
+	if err != nil {
+		return domain.ExportJob{}, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Get returns the job, with a fresh signed download link if it is finished.
+func (s *JobService) Get(ctx context.Context, id uuid.UUID) (domain.ExportJob, error) {
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return domain.ExportJob{}, err
+	}
+	if job.Status == domain.ExportDone {
+		expires := time.Now().Add(s.linkTTL).Truncate(time.Second)
+		query := url.Values{}
+		query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+		query.Set("signature", s.sign(job.ID, expires.Unix()))
+		job.DownloadURL = "/exports/" + job.ID.String() + "/download?" + query.Encode()
+		job.ExpiresAt = &expires
+	}
+	return job, nil
+}
+
+// Open checks a download link and opens the job's file. The caller must close it.
+func (s *JobService) Open(ctx context.Context, id uuid.UUID, expires string, signature string) (*os.File, domain.ExportJob, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(id, unix))) {
+		return nil, domain.ExportJob{}, ErrInvalidSignature
+	}
+	if time.Now().Unix() > unix {
+		return nil, domain.ExportJob{}, ErrLinkExpired
+	}
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, domain.ExportJob{}, err
+	}
+	if job.Status != domain.ExportDone {
+		return nil, domain.ExportJob{}, ErrNotReady
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, domain.ExportJob{}, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return file, job, nil
+}
+
+// Filename is the name a finished job is downloaded as.
+func Filename(job domain.ExportJob) string {
+	return fmt.Sprintf("complaints-%s.%s.gz", job.CreatedAt.Format("20060102-150405"), job.Params.Format)
+}
+
+func (s *JobService) sign(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(id.String() + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run processes export jobs with the configured number of workers until ctx is cancelled.
+func (s *JobService) Run(ctx context.Context) {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		s.log.Error("failed to create export directory", slog.String("dir", s.dir), sl.Err(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	s.log.Info("export workers started", slog.Int("workers", s.workers), slog.String("dir", s.dir))
+	sweep := time.NewTicker(time.Hour)
+	defer sweep.Stop()
+	s.sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			s.log.Info("export workers stopped")
+			return
+		case <-sweep.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *JobService) work(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Drain the queue before waiting for the next tick.
+		for ctx.Err() == nil {
+			job, err := s.repo.ClaimNext(ctx, s.lease)
+			if errors.Is(err, storage.ErrExportJobNotFound) {
+				break
+			}
+			if err != nil {
+				s.log.Error("failed to claim export job", sl.Err(err))
+				break
+			}
+			s.process(ctx, job)
+		}
+	}
+}
+
+func (s *JobService) process(ctx context.Context, job domain.ExportJob) {
+	log := s.log.With(slog.String("job_id", job.ID.String()))
+	started := time.Now()
+
+	path, rows, size, err := s.write(ctx, job, log)
+	if ctx.Err() != nil {
+		// Shutting down: the lease runs out and another replica starts the job over.
+		log.Warn("export interrupted", sl.Err(err))
+		return
+	}
+	if err != nil {
+		log.Error("export failed", sl.Err(err))
+		if err := s.repo.MarkFailed(ctx, job.ID, err.Error()); err != nil {
+			log.Error("failed to mark export job as failed", sl.Err(err))
+		}
+		return
+	}
+
+	if err := s.repo.MarkDone(ctx, job.ID, path, size, int64(rows)); err != nil {
+		log.Error("failed to mark export job as done", sl.Err(err))
+		return
+	}
+	log.Info("export finished", slog.Int("rows", rows), slog.Int64("bytes", size), slog.Duration("took", time.Since(started)))
+}
+
+func (s *JobService) write(ctx context.Context, job domain.ExportJob, log *slog.Logger) (string, int, int64, error) {
+	filter, opts, err := s.exporter.ParseParams(job.Params)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	total, err := s.complaints.CountComplaints(ctx, filter)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if err := s.repo.UpdateProgress(ctx, job.ID, total, 0, s.lease); err != nil {
+		return "", 0, 0, err
+	}
+	opts.Progress = func(rows int) {
+		if err := s.repo.UpdateProgress(ctx, job.ID, total, int64(rows), s.lease); err != nil {
+			log.Error("failed to update export progress", sl.Err(err))
+		}
+	}
+
+	path := filepath.Join(s.dir, job.ID.String()+"."+string(opts.Format)+".gz")
+	// A unique name keeps a replica that picked the job up after a lost lease from writing
+	// into the same file; the finished file is renamed over path, so the last one wins whole.
+	file, err := os.CreateTemp(s.dir, job.ID.String()+"-*.tmp")
+	if err != nil {
+		return "", 0, 0, err
+	}
+	tmp := file.Name()
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	rows, err := s.exporter.Export(ctx, gz, filter, opts)
+	if err != nil {
+		return "", rows, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return "", rows, 0, err
+	}
+	if err := file.Close(); err != nil {
+		return "", rows, 0, err
+	}
+
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return "", rows, 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", rows, 0, err
+	}
+	return path, rows, info.Size(), nil
+}
+
+// sweep deletes jobs and files older than the retention period.
+func (s *JobService) sweep(ctx context.Context) {
+	paths, err := s.repo.DeleteFinishedOlderThan(ctx, s.retention)
+	if err != nil {
+		s.log.Error("failed to delete old export jobs", sl.Err(err))
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Error("failed to delete export file", slog.String("path", path), sl.Err(err))
+		}
+	}
+	if len(paths) > 0 {
+		s.log.Info("old exports deleted", slog.Int("count", len(paths)))
+	}
+}
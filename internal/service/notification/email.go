@@ -0,0 +1,111 @@
+package serviceNotification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+)
+
+// EmailNotifier tells students by email that their complaint was approved or rejected.
+// It is an outbox sink: Publish sends the message and returns an error if the server didn't
+// accept it, so the outbox keeps the event and retries it with its backoff.
+// The outbox also retries an event when another sink failed, so each event is claimed in
+// notification_sends before it is sent and only the first claim sends it. A replica that dies
+// between the claim and the send loses that email rather than risking it twice.
+type EmailNotifier struct {
+	students  repository.StudentRepository
+	sends     repository.NotificationRepository
+	mailer    Mailer
+	templates *templates
+	log       *slog.Logger
+
+	emailDomain string
+	linkBase    string
+}
+
+func NewEmailNotifier(students repository.StudentRepository, sends repository.NotificationRepository, mailer Mailer, cfg *config.Config, log *slog.Logger) (*EmailNotifier, error) {
+	tmpl, err := loadTemplates(cfg.SMTP.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmailNotifier{
+		students:    students,
+		sends:       sends,
+		mailer:      mailer,
+		templates:   tmpl,
+		log:         log.With(slog.String("component", "service/notification")),
+		emailDomain: cfg.SMTP.EmailDomain,
+		linkBase:    cfg.SMTP.LinkBase,
+	}, nil
+}
+
+func (n *EmailNotifier) Publish(ctx context.Context, event domain.Event) error {
+	if event.Type != domain.EventComplaintStatusChanged {
+		return nil
+	}
+	status := strings.ToLower(event.Complaint.Status)
+	if status != "approved" && status != "rejected" {
+		return nil
+	}
+	contact, err := n.resolve(ctx, event.Complaint.Barcode)
+	if errors.Is(err, storage.ErrStudentNotFound) {
+		n.log.Warn("no email address for student", slog.Int("barcode", event.Complaint.Barcode))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	msg, err := n.templates.render(contact.Locale, contact.Email, templateData{
+		Status:    status,
+		Answer:    event.Complaint.Answer.String,
+		Category:  event.Complaint.Category.Title,
+		CreatedAt: event.Complaint.CreatedAt.Format("02.01.2006 15:04"),
+		Link:      n.linkBase + event.Complaint.ID.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+
+	claimed, err := n.sends.ClaimSend(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+	if err := n.mailer.Send(ctx, msg); err != nil {
+		if releaseErr := n.sends.ReleaseSend(ctx, event.ID); releaseErr != nil {
+			return errors.Join(fmt.Errorf("failed to send email: %w", err), releaseErr)
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	n.log.Info("email sent", slog.String("event_id", event.ID.String()), slog.String("to", msg.To))
+	return nil
+}
+
+// resolve finds the student's address: an explicit contact wins, otherwise the
+// university address <barcode>@<domain> is used.
+func (n *EmailNotifier) resolve(ctx context.Context, barcode int) (domain.StudentContact, error) {
+	contact, err := n.students.GetContact(ctx, barcode)
+	if err == nil {
+		return contact, nil
+	}
+	if !errors.Is(err, storage.ErrStudentNotFound) || n.emailDomain == "" {
+		return domain.StudentContact{}, err
+	}
+	return domain.StudentContact{
+		Barcode: barcode,
+		Email:   strconv.Itoa(barcode) + "@" + n.emailDomain,
+		Locale:  n.templates.fallback,
+	}, nil
+}
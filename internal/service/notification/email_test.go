@@ -0,0 +1,120 @@
+package serviceNotification
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type noContacts struct{}
+
+func (noContacts) GetContact(context.Context, int) (domain.StudentContact, error) {
+	return domain.StudentContact{}, storage.ErrStudentNotFound
+}
+
+// claims stands in for notification_sends, which replicas share.
+type claims map[uuid.UUID]bool
+
+func (c claims) ClaimSend(_ context.Context, eventID uuid.UUID) (bool, error) {
+	if c[eventID] {
+		return false, nil
+	}
+	c[eventID] = true
+	return true, nil
+}
+
+func (c claims) ReleaseSend(_ context.Context, eventID uuid.UUID) error {
+	delete(c, eventID)
+	return nil
+}
+
+// fakeMailer fails while err is set and records what it sent otherwise.
+type fakeMailer struct {
+	err  error
+	sent []Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func testNotifier(t *testing.T, sends claims, mailer Mailer) *EmailNotifier {
+	t.Helper()
+	cfg := &config.Config{SMTP: config.SMTP{Locale: "ru", EmailDomain: "astanait.edu.kz", LinkBase: "https://example.com/complaints/"}}
+	notifier, err := NewEmailNotifier(noContacts{}, sends, mailer, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return notifier
+}
+
+func statusChanged(status string) domain.Event {
+	return domain.Event{
+		ID:   uuid.New(),
+		Type: domain.EventComplaintStatusChanged,
+		Complaint: domain.Complaint{
+			ID:      uuid.New(),
+			Barcode: 242590,
+			Status:  status,
+		},
+	}
+}
+
+func TestPublishReturnsSendError(t *testing.T) {
+	mailer := &fakeMailer{err: errors.New("connection refused")}
+	notifier := testNotifier(t, claims{}, mailer)
+	event := statusChanged("approved")
+
+	if err := notifier.Publish(context.Background(), event); err == nil {
+		t.Fatal("Publish succeeded although the mailer failed; the outbox would drop the email")
+	}
+
+	// The outbox retries the event, and this time the server accepts it.
+	mailer.err = nil
+	if err := notifier.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish on retry: %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "242590@astanait.edu.kz" {
+		t.Fatalf("sent = %+v", mailer.sent)
+	}
+}
+
+func TestPublishSendsEventOnce(t *testing.T) {
+	mailer := &fakeMailer{}
+	sends := claims{}
+	event := statusChanged("rejected")
+
+	// The outbox retries the event after another sink failed, possibly on another replica.
+	for range 2 {
+		if err := testNotifier(t, sends, mailer).Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d emails for one event, want 1", len(mailer.sent))
+	}
+}
+
+func TestPublishSkipsPending(t *testing.T) {
+	mailer := &fakeMailer{}
+	notifier := testNotifier(t, claims{}, mailer)
+
+	if err := notifier.Publish(context.Background(), statusChanged("pending")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("sent = %+v, want nothing for a pending complaint", mailer.sent)
+	}
+}
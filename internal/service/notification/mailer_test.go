@@ -0,0 +1,200 @@
+package serviceNotification
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"complaint_server/internal/config"
+)
+
+// received is one message accepted by smtpServer.
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer is just enough of an SMTP server for SMTPMailer with SMTP_TLS=none. A reply
+// code in reject makes the server answer RCPT TO with it.
+func smtpServer(t *testing.T, reject string) (string, int, <-chan received) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan received, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, reject, messages)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func serveSMTP(conn net.Conn, reject string, messages chan<- received) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var msg received
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 localhost")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			if reject != "" {
+				reply(reject + " mailbox unavailable")
+				continue
+			}
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case verb == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			msg.data = data.String()
+			messages <- msg
+			reply("250 OK")
+		case verb == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func testMailer(t *testing.T, host string, port int) *SMTPMailer {
+	t.Helper()
+	mailer, err := NewSMTPMailer(config.SMTP{
+		Host: host,
+		Port: port,
+		From: "Complaints <no-reply@astanait.edu.kz>",
+		TLS:  TLSNone,
+	}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mailer
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, messages := smtpServer(t, "")
+	mailer := testMailer(t, host, port)
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "242590@astanait.edu.kz",
+		Subject: "Жалоба рассмотрена",
+		Text:    "Ваша жалоба одобрена.",
+		HTML:    "<p>Ваша жалоба одобрена.</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got received
+	select {
+	case got = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server received no message")
+	}
+	if got.from != "no-reply@astanait.edu.kz" {
+		t.Errorf("MAIL FROM = %q", got.from)
+	}
+	if len(got.to) != 1 || got.to[0] != "242590@astanait.edu.kz" {
+		t.Errorf("RCPT TO = %q", got.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Жалоба рассмотрена" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+
+	var types []string
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		// multipart.Reader undoes the quoted-printable encoding.
+		body, _ := io.ReadAll(part)
+		if !strings.Contains(string(body), "Ваша жалоба одобрена.") {
+			t.Errorf("%s part = %q", part.Header.Get("Content-Type"), body)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if strings.Join(types, ",") != "text/plain; charset=utf-8,text/html; charset=utf-8" {
+		t.Errorf("parts = %q", types)
+	}
+}
+
+func TestSMTPMailerSendRejected(t *testing.T) {
+	host, port, _ := smtpServer(t, "550")
+	mailer := testMailer(t, host, port)
+
+	err := mailer.Send(context.Background(), Message{To: "242590@astanait.edu.kz", Subject: "s", Text: "t", HTML: "h"})
+	if err == nil || !strings.Contains(err.Error(), "RCPT TO failed") {
+		t.Fatalf("Send = %v, want RCPT TO failure", err)
+	}
+}
+
+func TestSMTPMailerUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	mailer := testMailer(t, "127.0.0.1", port)
+	err = mailer.Send(context.Background(), Message{To: "242590@astanait.edu.kz", Subject: "s", Text: "t", HTML: "h"})
+	if err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Fatalf("Send = %v, want a connection error", err)
+	}
+}
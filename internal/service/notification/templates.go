@@ -0,0 +1,78 @@
+package serviceNotification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"strings"
+	textTemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var locales = []string{"ru", "kk", "en"}
+
+type templateData struct {
+	Status    string
+	Answer    string
+	Category  string
+	CreatedAt string
+	Link      string
+}
+
+type localizedTemplate struct {
+	text *textTemplate.Template
+	html *htmlTemplate.Template
+}
+
+// templates holds the parsed status change templates for every supported locale.
+type templates struct {
+	byLocale map[string]localizedTemplate
+	fallback string
+}
+
+func loadTemplates(fallback string) (*templates, error) {
+	t := &templates{byLocale: make(map[string]localizedTemplate), fallback: fallback}
+	for _, locale := range locales {
+		text, err := textTemplate.ParseFS(templateFS, "templates/status_changed."+locale+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template: %w", locale, err)
+		}
+		html, err := htmlTemplate.ParseFS(templateFS, "templates/status_changed."+locale+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s html template: %w", locale, err)
+		}
+		t.byLocale[locale] = localizedTemplate{text: text, html: html}
+	}
+	if _, ok := t.byLocale[fallback]; !ok {
+		return nil, fmt.Errorf("unsupported default locale %q", fallback)
+	}
+	return t, nil
+}
+
+func (t *templates) render(locale string, to string, data templateData) (Message, error) {
+	tmpl, ok := t.byLocale[strings.ToLower(locale)]
+	if !ok {
+		tmpl = t.byLocale[t.fallback]
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
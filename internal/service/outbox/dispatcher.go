@@ -0,0 +1,153 @@
+package serviceOutbox
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/logger/sl"
+)
+
+var (
+	backlogSize     = expvar.NewInt("outbox_backlog_size")
+	backlogAge      = expvar.NewFloat("outbox_oldest_age_seconds")
+	publishedTotal  = expvar.NewInt("outbox_published_total")
+	failedTotal     = expvar.NewInt("outbox_failed_total")
+	lastClaimedSize = expvar.NewInt("outbox_last_batch_size")
+)
+
+// Sink receives events from the outbox. Delivery is at-least-once: a sink may see the same
+// event again after a crash or after another sink failed, so it has to be idempotent on event ID.
+type Sink interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+type Dispatcher struct {
+	repo  repository.OutboxRepository
+	sinks map[string]Sink
+	log   *slog.Logger
+
+	pollInterval time.Duration
+	batchSize    int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	retention    time.Duration
+}
+
+func NewDispatcher(repo repository.OutboxRepository, cfg *config.Config, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:         repo,
+		sinks:        make(map[string]Sink),
+		log:          log.With(slog.String("component", "service/outbox")),
+		pollInterval: config.ParseDuration(log, "OUTBOX_POLL_INTERVAL", cfg.Outbox.PollInterval, time.Second),
+		batchSize:    cfg.Outbox.BatchSize,
+		baseBackoff:  config.ParseDuration(log, "OUTBOX_BASE_BACKOFF", cfg.Outbox.BaseBackoff, time.Second),
+		maxBackoff:   config.ParseDuration(log, "OUTBOX_MAX_BACKOFF", cfg.Outbox.MaxBackoff, 5*time.Minute),
+		retention:    config.ParseDuration(log, "OUTBOX_RETENTION", cfg.Outbox.Retention, 7*24*time.Hour),
+	}
+}
+
+// Register adds a sink. It must be called before Run.
+func (d *Dispatcher) Register(name string, sink Sink) {
+	d.sinks[name] = sink
+}
+
+// Run dispatches outbox messages until ctx is cancelled. Several replicas may run it at once.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	d.log.Info("outbox dispatcher started", slog.Int("sinks", len(d.sinks)))
+	for {
+		select {
+		case <-ctx.Done():
+			d.log.Info("outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+			d.collectStats(ctx)
+		case <-cleanup.C:
+			deleted, err := d.repo.DeletePublishedBefore(ctx, d.retention)
+			if err != nil {
+				d.log.Error("failed to clean up outbox", sl.Err(err))
+				continue
+			}
+			d.log.Debug("outbox cleaned up", slog.Int64("deleted", deleted))
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	// A message whose lease expires before it is handled is picked up again, which is safe but wasteful.
+	lease := d.pollInterval + time.Minute
+	messages, err := d.repo.ClaimBatch(ctx, d.batchSize, lease)
+	if err != nil {
+		d.log.Error("failed to claim outbox messages", sl.Err(err))
+		return
+	}
+	lastClaimedSize.Set(int64(len(messages)))
+
+	for _, message := range messages {
+		attempts := message.Attempts + 1
+		if err := d.publish(ctx, message.Event); err != nil {
+			failedTotal.Add(1)
+			d.log.Warn("failed to publish outbox message",
+				slog.Int64("id", message.ID),
+				slog.String("event_type", string(message.Event.Type)),
+				slog.Int("attempt", attempts),
+				sl.Err(err),
+			)
+			if err := d.repo.MarkFailed(ctx, message.ID, attempts, err.Error(), d.backoff(attempts)); err != nil {
+				d.log.Error("failed to mark outbox message failed", sl.Err(err))
+			}
+			continue
+		}
+
+		if err := d.repo.MarkPublished(ctx, message.ID); err != nil {
+			d.log.Error("failed to mark outbox message published", sl.Err(err))
+			continue
+		}
+		publishedTotal.Add(1)
+	}
+}
+
+// publish hands the event to every sink and joins their errors.
+func (d *Dispatcher) publish(ctx context.Context, event domain.Event) error {
+	var errs []error
+	for name, sink := range d.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) collectStats(ctx context.Context) {
+	stats, err := d.repo.Stats(ctx)
+	if err != nil {
+		d.log.Error("failed to collect outbox stats", sl.Err(err))
+		return
+	}
+	backlogSize.Set(stats.Backlog)
+	backlogAge.Set(stats.OldestAge.Seconds())
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.maxBackoff {
+			return d.maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,66 @@
+package serviceStats
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/logger/sl"
+)
+
+// StatsService serves complaint analytics from materialized views that Run refreshes periodically,
+// so the numbers may lag behind the complaints table by up to one refresh interval.
+type StatsService struct {
+	repo            repository.StatsRepository
+	log             *slog.Logger
+	refreshInterval time.Duration
+}
+
+func NewStatsService(repo repository.StatsRepository, cfg *config.Config, log *slog.Logger) *StatsService {
+	return &StatsService{
+		repo:            repo,
+		log:             log.With(slog.String("component", "service/stats")),
+		refreshInterval: config.ParseDuration(log, "STATS_REFRESH_INTERVAL", cfg.Stats.RefreshInterval, 10*time.Minute),
+	}
+}
+
+func (s *StatsService) GetStats(ctx context.Context, filter domain.StatsFilter) (domain.ComplaintStats, error) {
+	if filter.Interval == "" {
+		filter.Interval = domain.IntervalDay
+	}
+	return s.repo.GetStats(ctx, filter)
+}
+
+// Run refreshes the statistics views until ctx is cancelled.
+func (s *StatsService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	s.log.Info("stats refresh worker started")
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("stats refresh worker stopped")
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+func (s *StatsService) refresh(ctx context.Context) {
+	started := time.Now()
+	refreshed, err := s.repo.Refresh(ctx)
+	if err != nil {
+		s.log.Error("failed to refresh stats views", sl.Err(err))
+		return
+	}
+	if !refreshed {
+		s.log.Debug("stats views are being refreshed by another replica")
+		return
+	}
+	s.log.Info("stats views refreshed", slog.Duration("took", time.Since(started)))
+}
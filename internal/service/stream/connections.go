@@ -0,0 +1,37 @@
+package serviceStream
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrTooManyConnections = errors.New("too many live connections for this student")
+
+const connectionsKey = "ws:connections:"
+
+// AcquireConnection reserves one of the student's live connections. The counter lives in Redis,
+// so the limit holds across replicas; it expires after ttl unless refreshed with TouchConnection,
+// which cleans up after a replica that died without releasing its connections. Without Redis
+// the limit is counted per replica.
+func (h *Hub) AcquireConnection(ctx context.Context, barcode int, limit int, ttl time.Duration) (func(), error) {
+	key := connectionsKey + strconv.Itoa(barcode)
+
+	if h.connections.IncrSliding(ctx, key, ttl) > int64(limit) {
+		h.connections.Decr(ctx, key)
+		return nil, ErrTooManyConnections
+	}
+
+	release := func() {
+		// The request context is already cancelled when the connection is released.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		h.connections.Decr(ctx, key)
+	}
+	return release, nil
+}
+
+func (h *Hub) TouchConnection(ctx context.Context, barcode int, ttl time.Duration) {
+	h.connections.Expire(ctx, connectionsKey+strconv.Itoa(barcode), ttl)
+}
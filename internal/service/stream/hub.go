@@ -0,0 +1,177 @@
+package serviceStream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/shared/logger/sl"
+	"complaint_server/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const subscriberBuffer = 64
+
+// Filter selects the events a subscriber wants. Empty fields accept everything.
+type Filter struct {
+	CategoryIDs []uuid.UUID
+	EventTypes  []domain.EventType
+	Barcode     int // Only complaints of this student
+}
+
+func (f Filter) accepts(event domain.Event) bool {
+	if f.Barcode != 0 && f.Barcode != event.Complaint.Barcode {
+		return false
+	}
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, event.Type) {
+		return false
+	}
+	if len(f.CategoryIDs) > 0 && !slices.Contains(f.CategoryIDs, event.Complaint.Category.ID) {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	events chan domain.Event
+	filter Filter
+}
+
+// Hub fans complaint events out to live subscribers of this replica.
+// Events reach every replica through Redis pub/sub: Publish is registered as an outbox sink,
+// and Run relays the channel into the local subscribers and the replay buffer.
+type Hub struct {
+	redis       *redis.Client
+	connections *storage.Counter
+	channel     string
+	log         *slog.Logger
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	replay      []domain.Event
+	replaySize  int
+}
+
+func NewHub(client *redis.Client, cfg *config.Config, log *slog.Logger) *Hub {
+	return &Hub{
+		redis:       client,
+		connections: storage.NewCounter(client),
+		channel:     cfg.Stream.Channel,
+		log:         log.With(slog.String("component", "service/stream")),
+		subscribers: make(map[*subscriber]struct{}),
+		replaySize:  cfg.Stream.ReplaySize,
+	}
+}
+
+// Publish sends the event to all replicas. While Redis is unavailable only the subscribers of
+// this replica get it.
+func (h *Hub) Publish(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	err = h.redis.Publish(ctx, h.channel, payload).Err()
+	if errors.Is(err, storage.ErrRedisUnavailable) {
+		h.broadcast(event)
+		return nil
+	}
+	return err
+}
+
+// Run relays events from Redis to local subscribers until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) {
+	pubsub := h.redis.Subscribe(ctx, h.channel)
+	defer pubsub.Close()
+
+	h.log.Info("event stream hub started", slog.String("channel", h.channel))
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			h.log.Info("event stream hub stopped")
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event domain.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				h.log.Error("failed to decode stream event", sl.Err(err))
+				continue
+			}
+			h.broadcast(event)
+		}
+	}
+}
+
+func (h *Hub) broadcast(event domain.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, seen := range h.replay {
+		if seen.ID == event.ID {
+			// The outbox delivers at-least-once, drop repeats.
+			return
+		}
+	}
+	h.replay = append(h.replay, event)
+	if len(h.replay) > h.replaySize {
+		h.replay = h.replay[len(h.replay)-h.replaySize:]
+	}
+
+	for sub := range h.subscribers {
+		if !sub.filter.accepts(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.log.Warn("stream subscriber is too slow, event dropped", slog.String("event_id", event.ID.String()))
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the events it missed after lastEventID,
+// if that event is still in the replay buffer. The returned cancel func must be called when done.
+func (h *Hub) Subscribe(filter Filter, lastEventID string) (<-chan domain.Event, []domain.Event, func()) {
+	sub := &subscriber{events: make(chan domain.Event, subscriberBuffer), filter: filter}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	missed := h.missedSince(lastEventID, filter)
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		delete(h.subscribers, sub)
+		h.mu.Unlock()
+	}
+	return sub.events, missed, cancel
+}
+
+func (h *Hub) missedSince(lastEventID string, filter Filter) []domain.Event {
+	if lastEventID == "" {
+		return nil
+	}
+	for i, event := range h.replay {
+		if event.ID.String() != lastEventID {
+			continue
+		}
+		var missed []domain.Event
+		for _, e := range h.replay[i+1:] {
+			if filter.accepts(e) {
+				missed = append(missed, e)
+			}
+		}
+		return missed
+	}
+	return nil
+}
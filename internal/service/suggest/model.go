@@ -0,0 +1,117 @@
+package serviceSuggest
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"complaint_server/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// minTokenLength drops short words such as prepositions, which say little about the category.
+const minTokenLength = 3
+
+// Model is a multinomial naive Bayes classifier over message words. It is saved as JSON.
+type Model struct {
+	TrainedAt  time.Time            `json:"trained_at"`
+	Samples    int                  `json:"samples"`
+	Vocabulary int                  `json:"vocabulary"`
+	Classes    map[uuid.UUID]*Class `json:"classes"`
+}
+
+// Class holds the word counts of one category.
+type Class struct {
+	Docs   int            `json:"docs"`
+	Tokens int            `json:"tokens"`
+	Counts map[string]int `json:"counts"`
+}
+
+// Tokenize splits text into lower-case words of at least minTokenLength letters or digits.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if utf8.RuneCountInString(f) >= minTokenLength {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+func Train(samples []domain.TrainingSample) *Model {
+	m := &Model{
+		TrainedAt: time.Now().UTC(),
+		Samples:   len(samples),
+		Classes:   make(map[uuid.UUID]*Class),
+	}
+	vocabulary := make(map[string]bool)
+	for _, sample := range samples {
+		class, ok := m.Classes[sample.CategoryID]
+		if !ok {
+			class = &Class{Counts: make(map[string]int)}
+			m.Classes[sample.CategoryID] = class
+		}
+		class.Docs++
+		for _, token := range Tokenize(sample.Message) {
+			class.Counts[token]++
+			class.Tokens++
+			vocabulary[token] = true
+		}
+	}
+	m.Vocabulary = len(vocabulary)
+	return m
+}
+
+// Predict returns the posterior probability of each category for the message, using
+// Laplace smoothing. It returns nil when the model has not seen any complaints.
+func (m *Model) Predict(text string) map[uuid.UUID]float64 {
+	if m == nil || m.Samples == 0 {
+		return nil
+	}
+	tokens := Tokenize(text)
+	if !m.knows(tokens) {
+		return nil
+	}
+
+	logs := make(map[uuid.UUID]float64, len(m.Classes))
+	best := math.Inf(-1)
+	for id, class := range m.Classes {
+		score := math.Log(float64(class.Docs) / float64(m.Samples))
+		denominator := float64(class.Tokens + m.Vocabulary + 1)
+		for _, token := range tokens {
+			score += math.Log(float64(class.Counts[token]+1) / denominator)
+		}
+		logs[id] = score
+		best = math.Max(best, score)
+	}
+
+	// Normalize with log-sum-exp so that long messages don't underflow.
+	var sum float64
+	for _, score := range logs {
+		sum += math.Exp(score - best)
+	}
+	probs := make(map[uuid.UUID]float64, len(logs))
+	for id, score := range logs {
+		probs[id] = math.Exp(score-best) / sum
+	}
+	return probs
+}
+
+// knows reports whether any of the tokens was seen in training. Without one, Predict
+// would only repeat the category sizes.
+func (m *Model) knows(tokens []string) bool {
+	for _, token := range tokens {
+		for _, class := range m.Classes {
+			if class.Counts[token] > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package serviceSuggest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// SuggestService proposes categories for draft complaints. Scores combine admin keyword
+// rules with a naive Bayes model trained on past complaints, whose categories include any
+// corrections made by admins. Run retrains the model periodically and keeps it on disk.
+type SuggestService struct {
+	repo       repository.SuggestRepository
+	categories repository.CategoryRepository
+	log        *slog.Logger
+	cfg        config.Suggest
+
+	retrainInterval time.Duration
+	trainWindow     time.Duration
+
+	mu    sync.RWMutex
+	model *Model
+
+	// records holds suggestions to record for new complaints until Run gets to them.
+	records chan record
+}
+
+// recordQueueSize bounds the suggestions waiting to be recorded; more are dropped, since they
+// only feed the accuracy report.
+const recordQueueSize = 256
+
+var ErrRecordQueueFull = errors.New("suggestion record queue is full")
+
+type record struct {
+	complaintID uuid.UUID
+	message     string
+}
+
+func NewSuggestService(repo repository.SuggestRepository, categories repository.CategoryRepository, cfg *config.Config, log *slog.Logger) *SuggestService {
+	s := &SuggestService{
+		repo:            repo,
+		categories:      categories,
+		log:             log.With(slog.String("component", "service/suggest")),
+		cfg:             cfg.Suggest,
+		retrainInterval: config.ParseDuration(log, "SUGGEST_RETRAIN_INTERVAL", cfg.Suggest.RetrainInterval, 24*time.Hour),
+		trainWindow:     config.ParseDuration(log, "SUGGEST_TRAIN_WINDOW", cfg.Suggest.TrainWindow, 365*24*time.Hour),
+		records:         make(chan record, recordQueueSize),
+	}
+	if err := s.load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Error("failed to load category model", sl.Err(err))
+	}
+	return s
+}
+
+// Suggest returns up to SUGGEST_LIMIT categories for the message, best first.
+func (s *SuggestService) Suggest(ctx context.Context, message string) ([]domain.CategorySuggestion, error) {
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	rules, err := s.repo.KeywordRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyword rules: %w", err)
+	}
+
+	s.mu.RLock()
+	probs := s.model.Predict(message)
+	s.mu.RUnlock()
+
+	text := strings.ToLower(message)
+	matched := make(map[uuid.UUID][]string)
+	hits := 0
+	for _, rule := range rules {
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(text, keyword) {
+				matched[rule.CategoryID] = append(matched[rule.CategoryID], keyword)
+				hits++
+			}
+		}
+	}
+
+	// Each source is weighted only when it has something to say about the message.
+	ruleWeight := s.cfg.RuleWeight
+	switch {
+	case hits == 0:
+		ruleWeight = 0
+	case probs == nil:
+		ruleWeight = 1
+	}
+
+	suggestions := make([]domain.CategorySuggestion, 0, len(categories))
+	for _, category := range categories {
+		score := (1 - ruleWeight) * probs[category.ID]
+		if hits > 0 {
+			score += ruleWeight * float64(len(matched[category.ID])) / float64(hits)
+		}
+		if score < s.cfg.MinScore {
+			continue
+		}
+		suggestions = append(suggestions, domain.CategorySuggestion{
+			CategoryID: category.ID,
+			Title:      category.Title,
+			Score:      score,
+			Keywords:   matched[category.ID],
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > s.cfg.Limit {
+		suggestions = suggestions[:s.cfg.Limit]
+	}
+	return suggestions, nil
+}
+
+// Record queues the top suggestion for a new complaint to be stored by Run, so Accuracy can later
+// compare it with the category the complaint ends up in. It never waits for the database.
+func (s *SuggestService) Record(complaintID uuid.UUID, message string) error {
+	select {
+	case s.records <- record{complaintID: complaintID, message: message}:
+		return nil
+	default:
+		return ErrRecordQueueFull
+	}
+}
+
+func (s *SuggestService) record(ctx context.Context, complaintID uuid.UUID, message string) error {
+	suggestions, err := s.Suggest(ctx, message)
+	if err != nil || len(suggestions) == 0 {
+		return err
+	}
+	return s.repo.RecordSuggestion(ctx, complaintID, suggestions[0].CategoryID)
+}
+
+func (s *SuggestService) Accuracy(ctx context.Context, from *time.Time, to *time.Time) (domain.SuggestionAccuracy, error) {
+	acc, err := s.repo.Accuracy(ctx, from, to)
+	if err != nil {
+		return domain.SuggestionAccuracy{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.model != nil {
+		trainedAt := s.model.TrainedAt
+		acc.ModelTrainedAt = &trainedAt
+		acc.ModelSamples = s.model.Samples
+	}
+	return acc, nil
+}
+
+func (s *SuggestService) KeywordRules(ctx context.Context) ([]domain.KeywordRule, error) {
+	return s.repo.KeywordRules(ctx)
+}
+
+// SetKeywords replaces the keyword rules of a category. Keywords are matched ignoring case.
+func (s *SuggestService) SetKeywords(ctx context.Context, categoryID uuid.UUID, keywords []string) error {
+	cleaned := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			cleaned = append(cleaned, keyword)
+		}
+	}
+	return s.repo.SetKeywords(ctx, categoryID, cleaned)
+}
+
+// Retrain trains a new model from recent complaints, saves it and starts using it.
+func (s *SuggestService) Retrain(ctx context.Context) (*Model, error) {
+	samples, err := s.repo.TrainingSamples(ctx, time.Now().Add(-s.trainWindow), s.cfg.MaxSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get training samples: %w", err)
+	}
+	model := Train(samples)
+	if err := s.save(model); err != nil {
+		return nil, fmt.Errorf("failed to save category model: %w", err)
+	}
+
+	s.mu.Lock()
+	s.model = model
+	s.mu.Unlock()
+	return model, nil
+}
+
+// Run retrains the model every SUGGEST_RETRAIN_INTERVAL until ctx is cancelled,
+// and right away when no saved model was found.
+func (s *SuggestService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.retrainInterval)
+	defer ticker.Stop()
+
+	s.log.Info("category model worker started")
+	go s.recordLoop(ctx)
+	s.mu.RLock()
+	loaded := s.model != nil
+	s.mu.RUnlock()
+	if !loaded {
+		s.retrain(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("category model worker stopped")
+			return
+		case <-ticker.C:
+			s.retrain(ctx)
+		}
+	}
+}
+
+// recordLoop stores queued suggestions apart from retraining, which can take a while.
+func (s *SuggestService) recordLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rec := <-s.records:
+			if err := s.record(ctx, rec.complaintID, rec.message); err != nil {
+				s.log.Warn("failed to record category suggestion", slog.String("complaint_id", rec.complaintID.String()), sl.Err(err))
+			}
+		}
+	}
+}
+
+func (s *SuggestService) retrain(ctx context.Context) {
+	started := time.Now()
+	model, err := s.Retrain(ctx)
+	if err != nil {
+		s.log.Error("failed to retrain category model", sl.Err(err))
+		return
+	}
+	s.log.Info("category model retrained",
+		slog.Int("samples", model.Samples),
+		slog.Int("vocabulary", model.Vocabulary),
+		slog.Duration("took", time.Since(started)),
+	)
+}
+
+func (s *SuggestService) load() error {
+	data, err := os.ReadFile(s.cfg.ModelPath)
+	if err != nil {
+		return err
+	}
+	var model Model
+	if err := json.Unmarshal(data, &model); err != nil {
+		return err
+	}
+	s.model = &model
+	return nil
+}
+
+// save writes the model to a temporary file first, so a crash never leaves a partial model behind.
+func (s *SuggestService) save(model *Model) error {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.ModelPath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.cfg.ModelPath), filepath.Base(s.cfg.ModelPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.cfg.ModelPath)
+}
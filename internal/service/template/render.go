@@ -0,0 +1,159 @@
+package serviceTemplate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"complaint_server/internal/domain"
+)
+
+const (
+	// MaxBodyLength limits the size of a template body.
+	MaxBodyLength = 4000
+	// maxOutputLength stops templates that expand into very long answers.
+	maxOutputLength = 8000
+
+	createdAtLayout = "02.01.2006 15:04"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrRender          = errors.New("failed to render template")
+)
+
+// Variables lists the placeholders every template can use. Overrides with the same name
+// replace their values, other overrides are read with {{var "name"}}.
+var Variables = []string{"student_barcode", "category", "created_at", "ticket_number"}
+
+// TicketNumber is the short form of a complaint ID that students see in answers.
+func TicketNumber(complaint domain.Complaint) string {
+	return strings.ToUpper(complaint.ID.String()[:8])
+}
+
+// disabledBuiltins are the text/template builtins a template can't use. The comparisons,
+// and, or, not and len stay available for conditions.
+var disabledBuiltins = []string{"call", "printf", "print", "println", "index", "slice", "html", "js", "urlquery"}
+
+// funcs is the whole function set available to a template. Templates are executed with
+// no data, so they can reach nothing but these functions and the text/template builtins.
+func funcs(complaint domain.Complaint, overrides map[string]string) template.FuncMap {
+	values := map[string]string{
+		"student_barcode": strconv.Itoa(complaint.Barcode),
+		"category":        complaint.Category.Title,
+		"created_at":      complaint.CreatedAt.Format(createdAtLayout),
+		"ticket_number":   TicketNumber(complaint),
+	}
+	for name, value := range overrides {
+		values[name] = value
+	}
+
+	m := template.FuncMap{
+		"var": func(name string) (string, error) {
+			value, ok := values[name]
+			if !ok {
+				return "", fmt.Errorf("variable %q is not set", name)
+			}
+			return value, nil
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+	}
+	// call could invoke functions smuggled in through values, and the formatting and escaping
+	// builtins can expand a short body far past maxOutputLength, so they are disabled.
+	for _, name := range disabledBuiltins {
+		m[name] = func(...interface{}) (string, error) {
+			return "", fmt.Errorf("%s is not allowed", name)
+		}
+	}
+	for _, name := range Variables {
+		value := values[name]
+		m[name] = func() string { return value }
+	}
+	return m
+}
+
+// compile checks the syntax of a template body. Functions are bound to a placeholder
+// complaint, because text/template needs to know them when parsing.
+func compile(body string) (*template.Template, error) {
+	if len(body) > MaxBodyLength {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrInvalidTemplate, MaxBodyLength)
+	}
+	t, err := template.New("answer").Option("missingkey=error").Funcs(funcs(domain.Complaint{}, nil)).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	if len(t.Templates()) > 1 {
+		return nil, fmt.Errorf("%w: define and block are not allowed", ErrInvalidTemplate)
+	}
+	if err := checkNodes(t.Tree.Root); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	return t, nil
+}
+
+// checkNodes rejects range loops, which could run for a long time with an integer bound,
+// and calls to other templates.
+func checkNodes(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("template calls are not allowed")
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNodes(child); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	}
+	return nil
+}
+
+func checkBranch(b *parse.BranchNode) error {
+	if err := checkNodes(b.List); err != nil {
+		return err
+	}
+	return checkNodes(b.ElseList)
+}
+
+// Render fills a template body with the complaint's values and the overrides.
+func Render(body string, complaint domain.Complaint, overrides map[string]string) (string, error) {
+	t, err := compile(body)
+	if err != nil {
+		return "", err
+	}
+	var out limitedBuilder
+	if err := t.Funcs(funcs(complaint, overrides)).Execute(&out, nil); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRender, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// limitedBuilder fails writes past maxOutputLength.
+type limitedBuilder struct {
+	strings.Builder
+}
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxOutputLength {
+		return 0, fmt.Errorf("answer is longer than %d bytes", maxOutputLength)
+	}
+	return b.Builder.Write(p)
+}
@@ -0,0 +1,83 @@
+package serviceTemplate
+
+import (
+	"context"
+	"errors"
+
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrWrongCategory is returned when a category template is used for a complaint of another category.
+var ErrWrongCategory = errors.New("template belongs to another category")
+
+// TemplateService manages canned answers and renders them for complaints.
+type TemplateService struct {
+	repo       repository.AnswerTemplateRepository
+	complaints repository.ComplaintRepository
+}
+
+func NewTemplateService(repo repository.AnswerTemplateRepository, complaints repository.ComplaintRepository) *TemplateService {
+	return &TemplateService{repo: repo, complaints: complaints}
+}
+
+func (s *TemplateService) Create(ctx context.Context, template domain.AnswerTemplate) (domain.AnswerTemplate, error) {
+	if _, err := compile(template.Body); err != nil {
+		return domain.AnswerTemplate{}, err
+	}
+	id, err := s.repo.Create(ctx, template)
+	if err != nil {
+		return domain.AnswerTemplate{}, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *TemplateService) GetAll(ctx context.Context, categoryID *uuid.UUID) ([]domain.AnswerTemplate, error) {
+	return s.repo.GetAll(ctx, categoryID)
+}
+
+func (s *TemplateService) GetByID(ctx context.Context, id uuid.UUID) (domain.AnswerTemplate, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *TemplateService) Update(ctx context.Context, id uuid.UUID, template domain.AnswerTemplate) error {
+	if _, err := compile(template.Body); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, id, template)
+}
+
+func (s *TemplateService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Answer renders the template for the complaint, checking that the template applies to its category.
+func (s *TemplateService) Answer(template domain.AnswerTemplate, complaint domain.Complaint, overrides map[string]string) (string, error) {
+	if !template.AppliesTo(complaint.Category.ID) {
+		return "", ErrWrongCategory
+	}
+	return Render(template.Body, complaint, overrides)
+}
+
+// AnswerByID loads the template and renders it for the complaint.
+func (s *TemplateService) AnswerByID(ctx context.Context, templateID uuid.UUID, complaint domain.Complaint, overrides map[string]string) (string, error) {
+	template, err := s.repo.GetByID(ctx, templateID)
+	if err != nil {
+		return "", err
+	}
+	return s.Answer(template, complaint, overrides)
+}
+
+// Preview renders a stored template, or an unsaved body when templateID is nil, for an existing complaint.
+func (s *TemplateService) Preview(ctx context.Context, templateID *uuid.UUID, body string, complaintID uuid.UUID, overrides map[string]string) (string, error) {
+	complaint, err := s.complaints.GetComplaintByUUID(ctx, complaintID)
+	if err != nil {
+		return "", err
+	}
+	if templateID != nil {
+		return s.AnswerByID(ctx, *templateID, complaint, overrides)
+	}
+	return Render(body, complaint, overrides)
+}
@@ -0,0 +1,281 @@
+package serviceWebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"complaint_server/internal/config"
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/shared/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	deliveryLogLimit = 100
+)
+
+type WebhookService struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	log    *slog.Logger
+
+	pollInterval time.Duration
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	batchSize    int
+}
+
+func NewWebhookService(repo repository.WebhookRepository, cfg *config.Config, log *slog.Logger) *WebhookService {
+	return &WebhookService{
+		repo:         repo,
+		client:       &http.Client{Timeout: config.ParseDuration(log, "WEBHOOK_REQUEST_TIMEOUT", cfg.Webhooks.RequestTimeout, 10*time.Second)},
+		log:          log.With(slog.String("component", "service/webhook")),
+		pollInterval: config.ParseDuration(log, "WEBHOOK_POLL_INTERVAL", cfg.Webhooks.PollInterval, 5*time.Second),
+		baseBackoff:  config.ParseDuration(log, "WEBHOOK_BASE_BACKOFF", cfg.Webhooks.BaseBackoff, 30*time.Second),
+		maxBackoff:   config.ParseDuration(log, "WEBHOOK_MAX_BACKOFF", cfg.Webhooks.MaxBackoff, 6*time.Hour),
+		maxAttempts:  cfg.Webhooks.MaxAttempts,
+		batchSize:    cfg.Webhooks.BatchSize,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	if subscription.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return domain.WebhookSubscription{}, err
+		}
+		subscription.Secret = secret
+	}
+
+	id, err := s.repo.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return s.repo.GetSubscriptionByID(ctx, id)
+}
+
+func (s *WebhookService) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subscriptions, err := s.repo.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookService) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (domain.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// UpdateSubscription keeps the current secret when the update does not carry a new one.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id uuid.UUID, subscription domain.WebhookSubscription) error {
+	if subscription.Secret == "" {
+		current, err := s.repo.GetSubscriptionByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		subscription.Secret = current.Secret
+	}
+	return s.repo.UpdateSubscription(ctx, id, subscription)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, status domain.DeliveryStatus) ([]domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(ctx, &subscriptionID, status, deliveryLogLimit)
+}
+
+// GetDeadLetters returns deliveries of all subscriptions that ran out of attempts.
+func (s *WebhookService) GetDeadLetters(ctx context.Context) ([]domain.WebhookDelivery, error) {
+	return s.repo.GetDeliveries(ctx, nil, domain.DeliveryDead, deliveryLogLimit)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID uuid.UUID) error {
+	return s.repo.Redeliver(ctx, deliveryID)
+}
+
+// Publish queues a delivery of the event for every subscription that accepts it.
+func (s *WebhookService) Publish(ctx context.Context, event domain.Event) error {
+	subscriptions, err := s.repo.GetSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(event) {
+			continue
+		}
+		_, err := s.repo.CreateDelivery(ctx, domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run delivers queued webhooks until ctx is cancelled.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	s.log.Info("webhook delivery worker started")
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+			s.deliverDue(ctx)
+		}
+	}
+}
+
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	// The lease must outlive the HTTP timeout of the whole batch, otherwise another worker may pick it up again.
+	lease := s.client.Timeout*time.Duration(s.batchSize) + s.pollInterval
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, s.batchSize, lease)
+	if err != nil {
+		s.log.Error("failed to claim webhook deliveries", sl.Err(err))
+		return
+	}
+
+	subscriptions := make(map[uuid.UUID]domain.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.repo.GetSubscriptionByID(ctx, delivery.SubscriptionID)
+			if err != nil {
+				s.log.Error("failed to load webhook subscription", sl.Err(err))
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		s.attempt(ctx, subscription, delivery)
+	}
+}
+
+func (s *WebhookService) attempt(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) {
+	log := s.log.With(
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.String("subscription_id", subscription.ID.String()),
+	)
+	attempts := delivery.Attempts + 1
+
+	code, err := s.send(ctx, subscription, delivery)
+	if err == nil {
+		if err := s.repo.MarkDelivered(ctx, delivery.ID, attempts, code); err != nil {
+			log.Error("failed to mark webhook delivered", sl.Err(err))
+		}
+		return
+	}
+
+	status := domain.DeliveryPending
+	if attempts >= s.maxAttempts {
+		status = domain.DeliveryDead
+	}
+	log.Warn("webhook delivery failed",
+		slog.Int("attempt", attempts),
+		slog.String("status", string(status)),
+		sl.Err(err),
+	)
+	if err := s.repo.MarkFailed(ctx, delivery.ID, attempts, code, err.Error(), s.backoff(attempts), status); err != nil {
+		log.Error("failed to mark webhook failed", sl.Err(err))
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the delay after every failed attempt, capped at maxBackoff.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<payload>".
+// Receivers recompute it with their copy of the secret to verify the delivery.
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
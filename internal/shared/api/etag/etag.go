@@ -0,0 +1,57 @@
+package etag
+
+import (
+	"complaint_server/internal/shared/api/response"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Any is the expected version for If-Match: *, which matches whatever version is current.
+const Any = 0
+
+// Format renders a row version as a strong entity tag.
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func Set(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// Require reads the version expected by If-Match. Without the header it answers 428 and
+// returns false. Weak, listed or foreign tags can never match and come back as -1.
+func Require(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		render.Status(r, http.StatusPreconditionRequired)
+		render.JSON(w, r, response.Error("If-Match header with the ETag of the resource is required", http.StatusPreconditionRequired))
+		return 0, false
+	}
+	if value == "*" {
+		return Any, true
+	}
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if !ok {
+		return -1, true
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	version, err := strconv.Atoi(unquoted)
+	if !ok || err != nil || version <= 0 {
+		return -1, true
+	}
+	return version, true
+}
+
+// PreconditionFailed answers 412 with the current representation and its ETag, so the client
+// can merge its change and retry.
+func PreconditionFailed(w http.ResponseWriter, r *http.Request, version int, current any) {
+	Set(w, version)
+	render.Status(r, http.StatusPreconditionFailed)
+	render.JSON(w, r, response.Response{
+		StatusCode: http.StatusPreconditionFailed,
+		Message:    "The resource was changed since you read it",
+		Data:       current,
+	})
+}
@@ -0,0 +1,88 @@
+// Package mergepatch reads JSON Merge Patch (RFC 7386) bodies for flat resources.
+package mergepatch
+
+import (
+	"bytes"
+	"complaint_server/internal/shared/api/response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+const ContentType = "application/merge-patch+json"
+
+var (
+	ErrContentType = errors.New("content type must be " + ContentType)
+	ErrNotObject   = errors.New("merge patch must be a JSON object")
+)
+
+// FieldError rejects one member of the patch.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field %s %s", e.Field, e.Reason)
+}
+
+// Decode reads the patch into dst, a struct of pointer fields tagged with the member names.
+// Members outside allowed are rejected, as is null for members not listed in nullable.
+// It returns the members present in the patch, so a null can be told from an absent member.
+func Decode(r *http.Request, dst any, allowed []string, nullable ...string) (map[string]bool, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != ContentType {
+		return nil, ErrContentType
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, ErrNotObject
+	}
+
+	present := make(map[string]bool, len(members))
+	for name, value := range members {
+		if !slices.Contains(allowed, name) {
+			return nil, &FieldError{Field: name, Reason: "can't be updated"}
+		}
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) && !slices.Contains(nullable, name) {
+			return nil, &FieldError{Field: name, Reason: "can't be removed"}
+		}
+		present[name] = true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &FieldError{Field: typeErr.Field, Reason: "has the wrong type"}
+		}
+		return nil, err
+	}
+	return present, nil
+}
+
+// WriteError answers a patch Decode rejected: 415 for the wrong media type, 400 otherwise.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErr *FieldError
+	switch {
+	case errors.Is(err, ErrContentType):
+		render.Status(r, http.StatusUnsupportedMediaType)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusUnsupportedMediaType))
+	case errors.As(err, &fieldErr), errors.Is(err, ErrNotObject):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error(), http.StatusBadRequest))
+	default:
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("Failed to decode merge patch", http.StatusBadRequest))
+	}
+}
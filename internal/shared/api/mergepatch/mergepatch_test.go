@@ -0,0 +1,91 @@
+package mergepatch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type patch struct {
+	Message    *string `json:"message"`
+	CategoryID *int    `json:"category_id"`
+	Tags       *[]int  `json:"tags"`
+}
+
+var allowed = []string{"message", "category_id", "tags"}
+
+func patchRequest(contentType string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/complaints/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+func TestDecode(t *testing.T) {
+	var dst patch
+	present, err := Decode(patchRequest(ContentType+"; charset=utf-8", `{"message": "Нет горячей воды", "tags": null}`), &dst, allowed, "tags")
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if dst.Message == nil || *dst.Message != "Нет горячей воды" {
+		t.Errorf("message = %v", dst.Message)
+	}
+	if dst.Tags != nil || dst.CategoryID != nil {
+		t.Errorf("tags = %v, category_id = %v, want both nil", dst.Tags, dst.CategoryID)
+	}
+	// A null member is present, an absent one is not.
+	if !present["message"] || !present["tags"] || present["category_id"] {
+		t.Errorf("present = %v", present)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        error
+		field       string
+	}{
+		{name: "plain json", contentType: "application/json", body: `{}`, want: ErrContentType},
+		{name: "array", contentType: ContentType, body: `[]`, want: ErrNotObject},
+		{name: "null", contentType: ContentType, body: `null`, want: ErrNotObject},
+		{name: "malformed", contentType: ContentType, body: `{"message":`, want: ErrNotObject},
+		{name: "unknown member", contentType: ContentType, body: `{"status": "approved"}`, field: "status"},
+		{name: "null not nullable", contentType: ContentType, body: `{"message": null}`, field: "message"},
+		{name: "wrong type", contentType: ContentType, body: `{"category_id": "3"}`, field: "category_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst patch
+			_, err := Decode(patchRequest(tt.contentType, tt.body), &dst, allowed, "tags")
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Decode = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.field {
+				t.Fatalf("Decode = %v, want a FieldError for %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := map[error]int{
+		ErrContentType:             http.StatusUnsupportedMediaType,
+		ErrNotObject:               http.StatusBadRequest,
+		&FieldError{Field: "x"}:    http.StatusBadRequest,
+		errors.New("read timeout"): http.StatusBadRequest,
+	}
+	for err, want := range tests {
+		w := httptest.NewRecorder()
+		WriteError(w, httptest.NewRequest(http.MethodPatch, "/", nil), err)
+		if w.Code != want {
+			t.Errorf("WriteError(%v) = %d, want %d", err, w.Code, want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ type Response struct {
 	StatusCode int         `json:"statusCode" validate:"required"`
 	Message    string      `json:"message,omitempty"`
 	Data       interface{} `json:"data,omitempty"`
+	Meta       interface{} `json:"meta,omitempty"` // Extra information that doesn't belong to data
 }
 
 func Error(msg string, status int) Response {
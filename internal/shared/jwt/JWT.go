@@ -4,7 +4,9 @@ import (
 	"complaint_server/internal/domain"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -59,3 +61,15 @@ func EncodeJWT(jwtSecret string, tokenString string) (domain.Student, error) {
 	}
 	return student, nil
 }
+
+// Principal identifies who is calling: the student or admin in the bearer token, or the client
+// address for requests without a valid one.
+func Principal(r *http.Request, jwtSecret string) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token != "" {
+		if student, err := EncodeJWT(jwtSecret, token); err == nil {
+			return "user:" + strconv.Itoa(student.Barcode)
+		}
+	}
+	return "ip:" + r.RemoteAddr
+}
@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Mode says what a processor does with text its detector matched.
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // the processor is skipped
+	ModeMask   Mode = "mask"   // matches are replaced with a placeholder
+	ModeReject Mode = "reject" // the whole text is refused
+	ModeFlag   Mode = "flag"   // the text is kept as is and flagged for review
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeOff, ModeMask, ModeReject, ModeFlag:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown mode %q", s)
+}
+
+// Detector finds one kind of unwanted content.
+type Detector interface {
+	Name() string
+	// Mask returns text with every match replaced by a placeholder, and the number of matches.
+	Mask(text string) (string, int)
+}
+
+type pattern struct {
+	name        string
+	re          *regexp.Regexp
+	placeholder string
+}
+
+// NewPattern returns a detector matching re.
+func NewPattern(name string, re *regexp.Regexp, placeholder string) Detector {
+	return pattern{name: name, re: re, placeholder: placeholder}
+}
+
+func (p pattern) Name() string { return p.name }
+
+func (p pattern) Mask(text string) (string, int) {
+	n := 0
+	masked := p.re.ReplaceAllStringFunc(text, func(string) string {
+		n++
+		return p.placeholder
+	})
+	return masked, n
+}
+
+type Processor struct {
+	Detector Detector
+	Mode     Mode
+}
+
+// ErrRejected is matched by every RejectedError.
+var ErrRejected = errors.New("text rejected")
+
+// RejectedError is returned when a processor in reject mode matched the text.
+type RejectedError struct {
+	Detector string
+}
+
+func (e *RejectedError) Error() string { return "text contains " + e.Detector }
+
+func (e *RejectedError) Is(target error) bool { return target == ErrRejected }
+
+// Result is the outcome of running a Pipeline.
+type Result struct {
+	Text string
+	// Detectors that matched in mask mode and in flag mode.
+	Masked  []string
+	Flagged []string
+}
+
+// Changed reports whether masking altered the text.
+func (r Result) Changed() bool { return len(r.Masked) > 0 }
+
+// Pipeline runs processors over text in order, each seeing the output of the previous one.
+type Pipeline struct {
+	processors []Processor
+}
+
+func NewPipeline(processors ...Processor) *Pipeline {
+	return &Pipeline{processors: processors}
+}
+
+// Process returns the cleaned text, or a *RejectedError from the first rejecting processor that matched.
+func (p *Pipeline) Process(text string) (Result, error) {
+	result := Result{Text: text}
+	for _, proc := range p.processors {
+		if proc.Mode == ModeOff {
+			continue
+		}
+		masked, n := proc.Detector.Mask(result.Text)
+		if n == 0 {
+			continue
+		}
+		name := proc.Detector.Name()
+		switch proc.Mode {
+		case ModeReject:
+			return Result{}, &RejectedError{Detector: name}
+		case ModeFlag:
+			result.Flagged = append(result.Flagged, name)
+		default:
+			result.Text = masked
+			result.Masked = append(result.Masked, name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package redact
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed words/*.txt
+var wordFS embed.FS
+
+// Words returns the built-in profanity list for lang: ru, kk or en.
+func Words(lang string) ([]string, error) {
+	f, err := wordFS.Open("words/" + lang + ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("no word list for %q", lang)
+	}
+	defer f.Close()
+	return readWords(f)
+}
+
+// LoadWords reads a word list file in the format of the built-in lists.
+func LoadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readWords(f)
+}
+
+// readWords reads one word per line, skipping blank lines and # comments.
+func readWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, strings.ToLower(line))
+	}
+	return words, scanner.Err()
+}
+
+type wordList struct {
+	name  string
+	exact map[string]bool
+	stems []string
+}
+
+// NewWordList returns a detector matching whole words, ignoring case. A word ending in *
+// matches every word that starts with it, which covers Russian and Kazakh inflections.
+func NewWordList(name string, words []string) Detector {
+	l := wordList{name: name, exact: make(map[string]bool)}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if stem, ok := strings.CutSuffix(w, "*"); ok && stem != "" {
+			l.stems = append(l.stems, stem)
+		} else if w != "" {
+			l.exact[w] = true
+		}
+	}
+	return l
+}
+
+func (l wordList) Name() string { return l.name }
+
+func (l wordList) Mask(text string) (string, int) {
+	var b strings.Builder
+	n := 0
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if l.matches(strings.ToLower(word)) {
+			b.WriteString(Profanity)
+			n++
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	if n == 0 {
+		return text, 0
+	}
+	return b.String(), n
+}
+
+func (l wordList) matches(word string) bool {
+	if l.exact[word] {
+		return true
+	}
+	for _, stem := range l.stems {
+		if strings.HasPrefix(word, stem) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package redact
+
+import "regexp"
+
+// Placeholders that replace the redacted values.
+const (
+	Email     = "[email]"
+	Phone     = "[phone]"
+	IIN       = "[iin]"
+	Barcode   = "[barcode]"
+	Profanity = "[***]"
+)
+
+var (
+	emailPattern   = regexp.MustCompile(`[\p{L}0-9._%+\-]+@[\p{L}0-9.\-]+\.\p{L}{2,}`)
+	iinPattern     = regexp.MustCompile(`\b\d{12}\b`) // Kazakhstan individual identification number
+	phonePattern   = regexp.MustCompile(`(?:\+7|\b8)[\s\-(]*\d{3}[\s\-)]*\d{3}[\s\-]*\d{2}[\s\-]*\d{2}\b`)
+	barcodePattern = regexp.MustCompile(`\b\d{6}\b`) // Student ID
+)
+
+// EmailDetector finds e-mail addresses.
+func EmailDetector() Detector { return NewPattern("email", emailPattern, Email) }
+
+// PhoneDetector finds Kazakhstan phone numbers written with +7 or 8.
+func PhoneDetector() Detector { return NewPattern("phone", phonePattern, Phone) }
+
+// IINDetector finds 12-digit individual identification numbers.
+func IINDetector() Detector { return NewPattern("iin", iinPattern, IIN) }
+
+// Detectors run in order, so the longer number formats are matched before the shorter ones.
+var piiDetectors = []Detector{
+	EmailDetector(),
+	IINDetector(),
+	PhoneDetector(),
+	NewPattern("barcode", barcodePattern, Barcode),
+}
+
+// PII replaces e-mail addresses, phone numbers, IINs and student barcodes in text with placeholders.
+func PII(text string) string {
+	for _, d := range piiDetectors {
+		text, _ = d.Mask(text)
+	}
+	return text
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"complaint_server/internal/shared/logger/sl"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrRedisUnavailable = errors.New("redis is unavailable")
+
+// probeKey marks the breaker's own pings, which go through while it is open.
+type probeKey struct{}
+
+// Breaker is a circuit breaker installed as a hook on the Redis client. After threshold
+// consecutive connection failures it opens, and every command fails at once with
+// ErrRedisUnavailable instead of waiting for timeouts, so callers take their in-memory paths.
+// Run pings Redis in the background while it is open and closes it once Redis answers again.
+type Breaker struct {
+	client    *redis.Client
+	threshold int
+	interval  time.Duration
+	log       *slog.Logger
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openedAt  time.Time
+	lastError string
+	onRecover []func(ctx context.Context)
+}
+
+func newBreaker(client *redis.Client, threshold int, interval time.Duration, log *slog.Logger) *Breaker {
+	b := &Breaker{
+		client:    client,
+		threshold: max(threshold, 1),
+		interval:  interval,
+		log:       log.With(slog.String("component", "storage/redis")),
+	}
+	client.AddHook(b)
+	return b
+}
+
+// RedisStatus is what health checks report about Redis.
+type RedisStatus struct {
+	Available bool       `json:"available"`
+	Since     *time.Time `json:"unavailable_since,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+func (b *Breaker) Status() RedisStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return RedisStatus{Available: true}
+	}
+	since := b.openedAt
+	return RedisStatus{Since: &since, Error: b.lastError}
+}
+
+// OnRecover registers work to do once Redis is back, such as replaying missed invalidations.
+func (b *Breaker) OnRecover(fn func(ctx context.Context)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onRecover = append(b.onRecover, fn)
+}
+
+// Run probes Redis while the breaker is open until ctx is cancelled.
+func (b *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if b.isOpen() {
+				b.probe(ctx)
+			}
+		}
+	}
+}
+
+func (b *Breaker) probe(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(context.WithValue(ctx, probeKey{}, true), b.interval)
+	defer cancel()
+	if err := b.client.Ping(pingCtx).Err(); err != nil {
+		b.log.Debug("redis still unavailable", sl.Err(err))
+		return
+	}
+
+	b.mu.Lock()
+	downtime := time.Since(b.openedAt)
+	b.open, b.failures, b.lastError = false, 0, ""
+	callbacks := append([]func(ctx context.Context){}, b.onRecover...)
+	b.mu.Unlock()
+
+	b.log.Info("redis is available again", slog.Duration("downtime", downtime))
+	for _, fn := range callbacks {
+		fn(ctx)
+	}
+}
+
+func (b *Breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// trip opens the breaker right away, as when Redis can't be reached at startup.
+func (b *Breaker) trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = b.threshold
+	b.openLocked(err)
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isConnectionError(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && !b.open {
+		b.openLocked(err)
+	}
+}
+
+func (b *Breaker) openLocked(err error) {
+	b.open = true
+	b.openedAt = time.Now()
+	b.lastError = err.Error()
+	b.log.Warn("redis unavailable, falling back to in-memory behaviour", sl.Err(err))
+}
+
+// isConnectionError tells failures to reach Redis from replies such as a cache miss.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) {
+		return false
+	}
+	return true
+}
+
+func (b *Breaker) bypass(ctx context.Context) bool {
+	return b.isOpen() && ctx.Value(probeKey{}) == nil
+}
+
+func (b *Breaker) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if b.bypass(ctx) {
+			return nil, ErrRedisUnavailable
+		}
+		return next(ctx, network, addr)
+	}
+}
+
+func (b *Breaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if b.bypass(ctx) {
+			cmd.SetErr(ErrRedisUnavailable)
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmd)
+		if ctx.Value(probeKey{}) == nil {
+			b.record(err)
+		}
+		return err
+	}
+}
+
+func (b *Breaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if b.bypass(ctx) {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrRedisUnavailable)
+			}
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is how many local counters may pile up before expired ones are swept.
+const sweepThreshold = 1024
+
+// incrScript sets the expiry in the same step as the increment, so a count can't be left
+// without one when the client fails in between.
+var incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`)
+
+// decrScript leaves a count that has already expired alone instead of creating it at -1.
+var decrScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+return redis.call('DECR', KEYS[1])`)
+
+type localCount struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// Counter keeps expiring counters in Redis, so limits hold across replicas. While Redis is
+// unavailable it counts in memory instead, and each replica enforces the limit on its own.
+type Counter struct {
+	client *redis.Client
+
+	mu    sync.Mutex
+	local map[string]*localCount
+}
+
+func NewCounter(client *redis.Client) *Counter {
+	return &Counter{client: client, local: make(map[string]*localCount)}
+}
+
+// Incr adds one to key and returns the new count. The count expires ttl after the first Incr,
+// which makes it a fixed window.
+func (c *Counter) Incr(ctx context.Context, key string, ttl time.Duration) int64 {
+	count, err := incrScript.Run(ctx, c.client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return c.add(key, 1, ttl, false)
+	}
+	return count
+}
+
+// IncrSliding adds one to key and restarts its expiry, so the count lives as long as it is used.
+func (c *Counter) IncrSliding(ctx context.Context, key string, ttl time.Duration) int64 {
+	pipe := c.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return c.add(key, 1, ttl, true)
+	}
+	return incr.Val()
+}
+
+// Decr takes one off key if it is still counting.
+func (c *Counter) Decr(ctx context.Context, key string) {
+	if err := decrScript.Run(ctx, c.client, []string{key}).Err(); err != nil {
+		c.add(key, -1, 0, false)
+	}
+}
+
+func (c *Counter) Expire(ctx context.Context, key string, ttl time.Duration) {
+	if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
+		c.add(key, 0, ttl, true)
+	}
+}
+
+func (c *Counter) add(key string, delta int64, ttl time.Duration, refresh bool) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	count, ok := c.local[key]
+	if !ok || now.After(count.expiresAt) {
+		if delta <= 0 {
+			delete(c.local, key)
+			return 0
+		}
+		if len(c.local) >= sweepThreshold {
+			c.sweep(now)
+		}
+		count = &localCount{expiresAt: now.Add(ttl)}
+		c.local[key] = count
+	} else if refresh {
+		count.expiresAt = now.Add(ttl)
+	}
+	count.value += delta
+	return count.value
+}
+
+func (c *Counter) sweep(now time.Time) {
+	for key, count := range c.local {
+		if now.After(count.expiresAt) {
+			delete(c.local, key)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCounterIncrFixedWindow(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	counter := NewCounter(client)
+
+	if count := counter.Incr(ctx, "rate:k", time.Minute); count != 1 {
+		t.Fatalf("Incr = %d, want 1", count)
+	}
+	if ttl := mr.TTL("rate:k"); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want 1m", ttl)
+	}
+
+	mr.FastForward(40 * time.Second)
+	if count := counter.Incr(ctx, "rate:k", time.Minute); count != 2 {
+		t.Fatalf("Incr = %d, want 2", count)
+	}
+	if ttl := mr.TTL("rate:k"); ttl != 20*time.Second {
+		t.Fatalf("ttl = %v, want the window to keep its 20s", ttl)
+	}
+
+	mr.FastForward(30 * time.Second)
+	if count := counter.Incr(ctx, "rate:k", time.Minute); count != 1 {
+		t.Fatalf("Incr in a new window = %d, want 1", count)
+	}
+}
+
+func TestCounterIncrSliding(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	counter := NewCounter(client)
+
+	counter.IncrSliding(ctx, "k", time.Minute)
+	mr.FastForward(40 * time.Second)
+	if count := counter.IncrSliding(ctx, "k", time.Minute); count != 2 {
+		t.Fatalf("IncrSliding = %d, want 2", count)
+	}
+	if ttl := mr.TTL("k"); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want it restarted at 1m", ttl)
+	}
+}
+
+func TestCounterDecr(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	counter := NewCounter(client)
+
+	counter.Decr(ctx, "missing")
+	if mr.Exists("missing") {
+		t.Fatal("Decr created a key that had expired")
+	}
+
+	counter.Incr(ctx, "k", time.Minute)
+	counter.Incr(ctx, "k", time.Minute)
+	counter.Decr(ctx, "k")
+	if got, _ := mr.Get("k"); got != "1" {
+		t.Fatalf("k = %q, want 1", got)
+	}
+}
+
+func TestCounterFallsBackToMemory(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	mr.Close()
+	counter := NewCounter(client)
+
+	for want := int64(1); want <= 2; want++ {
+		if count := counter.Incr(ctx, "k", time.Minute); count != want {
+			t.Fatalf("Incr = %d, want %d", count, want)
+		}
+	}
+	counter.Decr(ctx, "k")
+	if count := counter.Incr(ctx, "k", time.Minute); count != 2 {
+		t.Fatalf("Incr after Decr = %d, want 2", count)
+	}
+
+	counter.Decr(ctx, "missing")
+	if count := counter.Incr(ctx, "missing", time.Minute); count != 1 {
+		t.Fatalf("Incr after Decr of a missing key = %d, want 1", count)
+	}
+}
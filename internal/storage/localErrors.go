@@ -3,13 +3,32 @@ package storage
 import "errors"
 
 var (
-	ErrCategoryNotFound = errors.New("categories not found")
-	ErrHasRelatedRows   = errors.New("there are related rows")
-	ErrCreateCategory   = errors.New("failed to create categories")
+	ErrCategoryNotFound   = errors.New("categories not found")
+	ErrHasRelatedRows     = errors.New("there are related rows")
+	ErrCreateCategory     = errors.New("failed to create categories")
+	ErrCategoryTitleTaken = errors.New("a category with this title already exists")
 	//----------------------
 	ErrCreateComplaint            = errors.New("failed to create categories")
 	ErrLimitOneComplaintInOneHour = errors.New("there are limit one complaint in one hour")
 	ErrComplaintNotFound          = errors.New("complaints not found")
+	ErrBulkTooLarge               = errors.New("too many complaints selected")
+	ErrPrimaryIsMerged            = errors.New("primary complaint is itself merged into another complaint")
+	ErrNotPublic                  = errors.New("complaints of this category are not public")
+	ErrOwnComplaint               = errors.New("you can't endorse your own complaint")
+	ErrNoOriginal                 = errors.New("complaint text was not masked, no original is kept")
+	ErrNotComplaintOwner          = errors.New("you are not the owner of this complaint")
+	ErrNotResolved                = errors.New("complaint is not resolved yet")
+	ErrRatingWindowClosed         = errors.New("the rating window for this complaint has closed")
+	ErrAlreadyRated               = errors.New("complaint has already been rated")
+	ErrVersionMismatch            = errors.New("the resource was changed since it was read")
+	ErrComplaintLocked            = errors.New("complaint is already being handled and can't be edited")
+	ErrComplaintMerged            = errors.New("complaint is merged into another complaint, change the primary one instead")
+	//----------------------
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrStudentNotFound      = errors.New("student contact not found")
+	ErrExportJobNotFound    = errors.New("export job not found")
+	ErrTemplateNotFound     = errors.New("answer template not found")
 	//----------------------
 	ErrDBConnection = errors.New("database connection error")
 	ErrScanFailure  = errors.New("failed to scan row from DB")
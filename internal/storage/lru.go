@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry is a cached value together with the version it was read at. A removed entry keeps
+// only the version, so a slow reader can't put back a value older than the write it lost to.
+type lruEntry struct {
+	key       string
+	value     string
+	version   int64
+	expiresAt time.Time
+	removed   bool
+}
+
+// lru is the in-process layer of TieredCache, bounded by entry count and age.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List // Most recently used first
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{size: size, ttl: ttl, items: make(map[string]*list.Element), order: list.New()}
+}
+
+func (l *lru) get(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.remove(el)
+		return "", false
+	}
+	if entry.removed {
+		return "", false
+	}
+	l.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// put keeps value for at most ttl, unless a newer version of the key is already known.
+func (l *lru) put(key string, value string, version int64, ttl time.Duration) {
+	if l.size <= 0 {
+		return
+	}
+	if ttl <= 0 || ttl > l.ttl {
+		ttl = l.ttl
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if version < entry.version && time.Now().Before(entry.expiresAt) {
+			return
+		}
+		*entry = lruEntry{key: key, value: value, version: version, expiresAt: time.Now().Add(ttl)}
+		l.order.MoveToFront(el)
+		return
+	}
+	l.insert(&lruEntry{key: key, value: value, version: version, expiresAt: time.Now().Add(ttl)})
+}
+
+// invalidate drops the value of key if it is older than version.
+func (l *lru) invalidate(key string, version int64) {
+	if l.size <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if entry.version >= version {
+			return
+		}
+		*entry = lruEntry{key: key, version: version, expiresAt: time.Now().Add(l.ttl), removed: true}
+		return
+	}
+	l.insert(&lruEntry{key: key, version: version, expiresAt: time.Now().Add(l.ttl), removed: true})
+}
+
+// drop removes the value of key after a write that couldn't reach Redis; the local version moves
+// past it so a value read before the write isn't put back.
+func (l *lru) drop(key string) {
+	if l.size <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		*entry = lruEntry{key: key, version: entry.version + 1, expiresAt: time.Now().Add(l.ttl), removed: true}
+		return
+	}
+	l.insert(&lruEntry{key: key, expiresAt: time.Now().Add(l.ttl), removed: true})
+}
+
+// version is the newest version of key this replica knows of.
+func (l *lru) version(key string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		return el.Value.(*lruEntry).version
+	}
+	return 0
+}
+
+func (l *lru) insert(entry *lruEntry) {
+	l.items[entry.key] = l.order.PushFront(entry)
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+		l1Evictions.Add(1)
+	}
+	l1Size.Set(int64(l.order.Len()))
+}
+
+func (l *lru) remove(el *list.Element) {
+	delete(l.items, el.Value.(*lruEntry).key)
+	l.order.Remove(el)
+	l1Size.Set(int64(l.order.Len()))
+}
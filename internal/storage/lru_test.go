@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUPutGet(t *testing.T) {
+	l := newLRU(10, time.Minute)
+	l.put("a", "1", 1, 0)
+
+	if value, ok := l.get("a"); !ok || value != "1" {
+		t.Fatalf("get = %q, %v", value, ok)
+	}
+	if _, ok := l.get("b"); ok {
+		t.Fatal("get of a missing key hit")
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLRU(2, time.Minute)
+	l.put("a", "1", 1, 0)
+	l.put("b", "2", 1, 0)
+	l.get("a")
+	l.put("c", "3", 1, 0)
+
+	if _, ok := l.get("b"); ok {
+		t.Error("b survived although it was used least recently")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := l.get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestLRUExpires(t *testing.T) {
+	l := newLRU(10, time.Minute)
+	l.put("a", "1", 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := l.get("a"); ok {
+		t.Fatal("get hit after the entry expired")
+	}
+}
+
+func TestLRUPutKeepsNewerVersion(t *testing.T) {
+	l := newLRU(10, time.Minute)
+	l.invalidate("a", 2)
+	l.put("a", "stale", 1, 0)
+
+	if _, ok := l.get("a"); ok {
+		t.Fatal("a value read before the invalidation was put back")
+	}
+
+	l.put("a", "fresh", 2, 0)
+	if value, ok := l.get("a"); !ok || value != "fresh" {
+		t.Fatalf("get = %q, %v", value, ok)
+	}
+}
+
+func TestLRUInvalidateIgnoresOlderVersion(t *testing.T) {
+	l := newLRU(10, time.Minute)
+	l.put("a", "1", 3, 0)
+	l.invalidate("a", 2)
+
+	if value, ok := l.get("a"); !ok || value != "1" {
+		t.Fatalf("get = %q, %v after an older invalidation", value, ok)
+	}
+}
+
+func TestLRUDrop(t *testing.T) {
+	l := newLRU(10, time.Minute)
+	l.put("a", "1", 3, 0)
+	l.drop("a")
+
+	if _, ok := l.get("a"); ok {
+		t.Fatal("get hit after drop")
+	}
+	if version := l.version("a"); version != 4 {
+		t.Fatalf("version = %d, want 4", version)
+	}
+	l.put("a", "1", 3, 0)
+	if _, ok := l.get("a"); ok {
+		t.Fatal("a value read before the drop was put back")
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	l := newLRU(0, time.Minute)
+	l.put("a", "1", 1, 0)
+
+	if _, ok := l.get("a"); ok {
+		t.Fatal("a cache of size 0 kept a value")
+	}
+}
@@ -0,0 +1,101 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const boardWhere = `
+	WHERE cat.is_public
+	  AND cat.archived_at IS NULL
+	  AND c.status = 'approved'
+	  AND c.resolved_at IS NOT NULL
+	  AND c.merged_into IS NULL
+	  AND NOT c.hidden_from_board
+	  AND ($1::uuid IS NULL OR c.category_id = $1::uuid)`
+
+func (c complaintRepo) GetBoard(ctx context.Context, filter domain.BoardFilter) ([]domain.BoardComplaint, int64, error) {
+	const op = "storage.postgres.GetBoard"
+
+	var total int64
+	err := c.db.QueryRow(ctx, `
+		SELECT count(*)
+		FROM complaints c
+		JOIN categories cat ON cat.uuid = c.category_id`+boardWhere, filter.CategoryID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := c.db.Query(ctx, `
+		SELECT c.uuid, cat.uuid, cat.title, c.message, c.status, COALESCE(c.answer, ''), c.endorsements,
+		       c.created_at, c.resolved_at
+		FROM complaints c
+		JOIN categories cat ON cat.uuid = c.category_id`+boardWhere+`
+		ORDER BY c.resolved_at DESC, c.uuid
+		LIMIT $2 OFFSET $3`,
+		filter.CategoryID, filter.PageSize, (filter.Page-1)*filter.PageSize,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	items := []domain.BoardComplaint{}
+	for rows.Next() {
+		var item domain.BoardComplaint
+		err := rows.Scan(
+			&item.ID,
+			&item.CategoryID,
+			&item.Category,
+			&item.Message,
+			&item.Status,
+			&item.Answer,
+			&item.Endorsements,
+			&item.CreatedAt,
+			&item.ResolvedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		item.ResolutionSeconds = int64(item.ResolvedAt.Sub(item.CreatedAt).Seconds())
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return items, total, nil
+}
+
+// SetHiddenFromBoard changes the board visibility of a complaint at expectedVersion and returns
+// its new version.
+func (c complaintRepo) SetHiddenFromBoard(ctx context.Context, id uuid.UUID, hidden bool, expectedVersion int) (int, error) {
+	const op = "storage.postgres.SetHiddenFromBoard"
+
+	var version int
+	err := c.db.QueryRow(ctx, `
+		UPDATE complaints
+		SET hidden_from_board = $2, version = version + 1
+		WHERE uuid = $1 AND ($3 = 0 OR version = $3)
+		RETURNING version`, id, hidden, expectedVersion,
+	).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err := c.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM complaints WHERE uuid = $1)`, id).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return 0, storage.ErrComplaintNotFound
+		}
+		return 0, storage.ErrVersionMismatch
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, nil
+}
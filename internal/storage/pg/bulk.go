@@ -0,0 +1,131 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+func (c complaintRepo) BulkUpdateStatus(ctx context.Context, update domain.BulkStatusUpdate, limit int) (domain.BulkResult, error) {
+	const op = "storage.postgres.BulkUpdateStatus"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the selected rows so the lifecycle check below sees their final status.
+	query := `SELECT c.uuid, c.status, c.merged_into IS NOT NULL FROM complaints c WHERE c.uuid = ANY($1) ORDER BY c.created_at FOR UPDATE`
+	args := []any{update.IDs}
+	if len(update.IDs) == 0 {
+		query = `SELECT c.uuid, c.status, c.merged_into IS NOT NULL FROM complaints c` + complaintFilterWhere + ` ORDER BY c.created_at LIMIT $5 FOR UPDATE`
+		args = append(filterArgs(update.Filter), limit+1)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	current := make(map[uuid.UUID]domain.ComplaintStatus)
+	merged := make(map[uuid.UUID]bool)
+	var order []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		var status domain.ComplaintStatus
+		var isMerged bool
+		if err := rows.Scan(&id, &status, &isMerged); err != nil {
+			rows.Close()
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		current[id] = status
+		merged[id] = isMerged
+		order = append(order, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(order) > limit {
+		return domain.BulkResult{}, storage.ErrBulkTooLarge
+	}
+
+	// Report listed IDs in the order they were given, including the missing ones.
+	if len(update.IDs) > 0 {
+		order = update.IDs
+	}
+
+	result := domain.BulkResult{BatchID: uuid.New(), Items: make([]domain.BulkItemResult, 0, len(order))}
+	for _, id := range order {
+		from, ok := current[id]
+		if !ok {
+			result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkNotFound})
+			result.NotFound++
+			continue
+		}
+		// A duplicate follows its primary, so a change of its own would be overwritten.
+		if merged[id] {
+			result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkSkipped, FromStatus: from, Message: storage.ErrComplaintMerged.Error()})
+			result.Skipped++
+			continue
+		}
+		if err := domain.CheckTransition(from, update.Status); err != nil {
+			result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkSkipped, FromStatus: from, Message: err.Error()})
+			result.Skipped++
+			continue
+		}
+
+		answer := update.Answer
+		if update.AnswerFor != nil {
+			complaint, err := loadComplaint(ctx, tx, id)
+			if err != nil {
+				return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+			}
+			if answer, err = update.AnswerFor(complaint); err != nil {
+				result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkSkipped, FromStatus: from, Message: err.Error()})
+				result.Skipped++
+				continue
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE complaints
+			SET status = $1, updated_at = CURRENT_TIMESTAMP, answer = $3, version = version + 1,
+				first_answered_at = `+firstAnsweredAt("$3")+`,
+				resolved_at = `+resolvedAt("$1")+`,
+				resolved_by = `+resolvedBy("$1", "$4")+`
+			WHERE uuid = $2`, update.Status, id, answer, update.Actor)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_audit (complaint_id, actor, action, from_status, to_status, answer, batch_id)
+			VALUES ($1, $2, 'bulk_status_change', $3, $4, $5, $6)`,
+			id, update.Actor, from, update.Status, answer, result.BatchID)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		complaint, err := loadComplaint(ctx, tx, id)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := writeOutbox(ctx, tx, domain.EventComplaintStatusChanged, complaint); err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := followPrimary(ctx, tx, id); err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkUpdated, FromStatus: from})
+		result.Updated++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
@@ -6,38 +6,41 @@ import (
 	"complaint_server/internal/storage"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 )
 
 type categoryRepo struct {
-	db    *pgxpool.Pool
-	redis *redis.Client
+	db *pgxpool.Pool
 }
 
-func NewCategoryRepo(db *Storage, redis *redis.Client) repository.CategoryRepository {
-	return &categoryRepo{db: db.db, redis: redis}
+func NewCategoryRepo(db *Storage) repository.CategoryRepository {
+	return &categoryRepo{db: db.db}
 }
 
-const (
-	allCategoriesKey = "cache:/categories"
-	categoryKey      = "cache:category:"
-)
-
 func (c *categoryRepo) Create(ctx context.Context, category domain.Category) (uuid.UUID, error) {
 	const op = "storage.categories.CreateCategory"
-	query := `INSERT INTO categories (title, description, answer) VALUES ($1, $2, $3) RETURNING uuid`
-	var categoryID uuid.UUID
-	err := c.db.QueryRow(ctx, query, category.Title, category.Description, category.Answer).Scan(&categoryID)
+
+	tx, err := c.db.Begin(ctx)
 	if err != nil {
 		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback(ctx)
 
-	c.redis.Del(ctx, allCategoriesKey)
+	query := `INSERT INTO categories (title, description, answer, is_public) VALUES ($1, $2, $3, $4) RETURNING uuid`
+	var categoryID uuid.UUID
+	err = tx.QueryRow(ctx, query, category.Title, category.Description, category.Answer, category.Public).Scan(&categoryID)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := recordCategoryVersion(ctx, tx, categoryID); err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
+	}
 
 	return categoryID, nil
 }
@@ -45,15 +48,7 @@ func (c *categoryRepo) Create(ctx context.Context, category domain.Category) (uu
 func (c *categoryRepo) GetAll(ctx context.Context) ([]domain.Category, error) {
 	const op = "storage.categories.GetAll"
 
-	cached, err := c.redis.Get(ctx, allCategoriesKey).Result()
-	if err == nil {
-		var categories []domain.Category
-		if err := json.Unmarshal([]byte(cached), &categories); err == nil {
-			return categories, nil
-		}
-	}
-
-	rows, err := c.db.Query(ctx, `SELECT uuid, title, description, answer FROM categories`)
+	rows, err := c.db.Query(ctx, `SELECT uuid, title, description, answer, is_public, version FROM categories WHERE archived_at IS NULL`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -62,36 +57,24 @@ func (c *categoryRepo) GetAll(ctx context.Context) ([]domain.Category, error) {
 	var categories []domain.Category
 	for rows.Next() {
 		var cat domain.Category
-		if err := rows.Scan(&cat.ID, &cat.Title, &cat.Description, &cat.Answer); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Title, &cat.Description, &cat.Answer, &cat.Public, &cat.Version); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		categories = append(categories, cat)
 	}
 
-	data, _ := json.Marshal(categories)
-	c.redis.Set(ctx, allCategoriesKey, data, 0)
-
 	return categories, nil
 }
 
 func (c *categoryRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Category, error) {
 	const op = "storage.categories.GetByID"
 
-	key := categoryKey + id.String()
-	cached, err := c.redis.Get(ctx, key).Result()
-	if err == nil {
-		var cat domain.Category
-		if err := json.Unmarshal([]byte(cached), &cat); err == nil {
-			return cat, nil
-		}
-	}
-
 	var category domain.Category
-	err = c.db.QueryRow(ctx, `
-		SELECT uuid, title, description, answer
+	err := c.db.QueryRow(ctx, `
+		SELECT uuid, title, description, answer, archived_at IS NOT NULL, is_public, version
 		FROM categories WHERE uuid = $1`,
 		id,
-	).Scan(&category.ID, &category.Title, &category.Description, &category.Answer)
+	).Scan(&category.ID, &category.Title, &category.Description, &category.Answer, &category.Archived, &category.Public, &category.Version)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return domain.Category{}, storage.ErrCategoryNotFound
@@ -100,42 +83,63 @@ func (c *categoryRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Catego
 		return domain.Category{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	data, _ := json.Marshal(category)
-	c.redis.Set(ctx, key, data, 0)
-
 	return category, nil
 }
 
-func (c *categoryRepo) Update(ctx context.Context, id uuid.UUID, category domain.Category) (uuid.UUID, error) {
+func (c *categoryRepo) Update(ctx context.Context, id uuid.UUID, category domain.Category, expectedVersion int) (uuid.UUID, error) {
 	const op = "storage.categories.Update"
 
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE categories
-		SET title = $1, description = $2, answer = $3
-		WHERE uuid = $4`
-	res, err := c.db.Exec(ctx, query, category.Title, category.Description, category.Answer, id)
+		SET title = $1, description = $2, answer = $3, is_public = $5, version = version + 1
+		WHERE uuid = $4 AND ($6 = 0 OR version = $6)`
+	res, err := tx.Exec(ctx, query, category.Title, category.Description, category.Answer, id, category.Public, expectedVersion)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return uuid.Nil, storage.ErrCategoryNotFound
+		return uuid.Nil, c.missOrMismatch(ctx, tx, id)
 	}
 
-	c.redis.Del(ctx, allCategoriesKey, categoryKey+id.String())
+	if err := recordCategoryVersion(ctx, tx, id); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
 
 	return id, nil
 }
 
-func (c *categoryRepo) Delete(ctx context.Context, id uuid.UUID) error {
+func (c *categoryRepo) Delete(ctx context.Context, id uuid.UUID, expectedVersion int) error {
 	const op = "storage.categories.Delete"
 
-	_, err := c.db.Exec(ctx, `DELETE FROM categories WHERE uuid = $1`, id)
+	res, err := c.db.Exec(ctx, `DELETE FROM categories WHERE uuid = $1 AND ($2 = 0 OR version = $2)`, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	c.redis.Del(ctx, allCategoriesKey, categoryKey+id.String())
+	if res.RowsAffected() == 0 && expectedVersion != 0 {
+		return c.missOrMismatch(ctx, c.db, id)
+	}
 
 	return nil
 }
+
+// missOrMismatch tells why a versioned write of a category matched no row.
+func (c *categoryRepo) missOrMismatch(ctx context.Context, q querier, id uuid.UUID) error {
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("storage.categories.missOrMismatch: %w", err)
+	}
+	if exists {
+		return storage.ErrVersionMismatch
+	}
+	return storage.ErrCategoryNotFound
+}
@@ -0,0 +1,101 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"context"
+	"fmt"
+)
+
+func (c *categoryRepo) GetAllIncludingArchived(ctx context.Context) ([]domain.Category, error) {
+	const op = "storage.categories.GetAllIncludingArchived"
+
+	categories, err := selectCategories(ctx, c.db, ``)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return categories, nil
+}
+
+func (c *categoryRepo) Import(ctx context.Context, entries []domain.CategoryEntry, archiveMissing bool, dryRun bool) (domain.CategoryImportResult, error) {
+	const op = "storage.categories.Import"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.CategoryImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the table against concurrent imports and category edits while the plan is applied.
+	existing, err := selectCategories(ctx, tx, ` FOR UPDATE`)
+	if err != nil {
+		return domain.CategoryImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := domain.PlanCategoryImport(existing, entries, archiveMissing)
+	result.DryRun = dryRun
+	if dryRun {
+		return result, nil
+	}
+
+	for i, change := range result.Changes {
+		switch change.Action {
+		case domain.ImportCreate:
+			err = tx.QueryRow(ctx, `
+				INSERT INTO categories (title, description, answer, archived_at)
+				VALUES ($1, $2, $3, CASE WHEN $4 THEN CURRENT_TIMESTAMP END)
+				RETURNING uuid`,
+				change.Entry.Title, change.Entry.Description, change.Entry.Answer, change.Entry.Archived,
+			).Scan(&result.Changes[i].Category.ID)
+			if err == nil {
+				id := result.Changes[i].Category.ID
+				result.Changes[i].ID = &id
+			}
+		case domain.ImportUpdate:
+			_, err = tx.Exec(ctx, `
+				UPDATE categories
+				SET title = $1, description = $2, answer = $3, version = version + 1,
+				    archived_at = CASE WHEN $4 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END
+				WHERE uuid = $5`,
+				change.Entry.Title, change.Entry.Description, change.Entry.Answer, change.Entry.Archived, *change.ID,
+			)
+		case domain.ImportArchive:
+			_, err = tx.Exec(ctx, `UPDATE categories SET archived_at = CURRENT_TIMESTAMP, version = version + 1 WHERE uuid = $1`, *change.ID)
+		default:
+			continue
+		}
+		if err == nil && change.Action != domain.ImportArchive {
+			err = recordCategoryVersion(ctx, tx, *result.Changes[i].ID)
+		}
+		if err != nil {
+			return domain.CategoryImportResult{}, fmt.Errorf("%s: %s %q: %w", op, change.Action, change.Title, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.CategoryImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Applied = true
+
+	return result, nil
+}
+
+func selectCategories(ctx context.Context, q querier, suffix string) ([]domain.Category, error) {
+	rows, err := q.Query(ctx, `
+		SELECT uuid, title, description, answer, archived_at IS NOT NULL, is_public, version
+		FROM categories
+		ORDER BY title`+suffix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []domain.Category
+	for rows.Next() {
+		var category domain.Category
+		if err := rows.Scan(&category.ID, &category.Title, &category.Description, &category.Answer, &category.Archived, &category.Public, &category.Version); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
@@ -0,0 +1,66 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+// recordCategoryVersion snapshots the current content of a category through q, which must
+// be the transaction that changed it. Nothing is recorded when the content is unchanged,
+// e.g. when only the public flag was edited.
+func recordCategoryVersion(ctx context.Context, q querier, id uuid.UUID) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO category_versions (category_id, version, title, description, answer)
+		SELECT c.uuid, COALESCE(v.version, 0) + 1, c.title, c.description, c.answer
+		FROM categories c
+		LEFT JOIN LATERAL (
+			SELECT version, title, description, answer
+			FROM category_versions
+			WHERE category_id = c.uuid
+			ORDER BY version DESC
+			LIMIT 1
+		) v ON true
+		WHERE c.uuid = $1
+		  AND (v.version IS NULL OR (v.title, v.description, v.answer) IS DISTINCT FROM (c.title, c.description, c.answer))`,
+		id,
+	)
+	return err
+}
+
+func (c *categoryRepo) GetVersions(ctx context.Context, id uuid.UUID) ([]domain.CategoryVersion, error) {
+	const op = "storage.categories.GetVersions"
+
+	var exists bool
+	if err := c.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return nil, storage.ErrCategoryNotFound
+	}
+
+	rows, err := c.db.Query(ctx, `
+		SELECT id, category_id, version, title, description, answer, created_at
+		FROM category_versions
+		WHERE category_id = $1
+		ORDER BY version DESC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var versions []domain.CategoryVersion
+	for rows.Next() {
+		var v domain.CategoryVersion
+		if err := rows.Scan(&v.ID, &v.CategoryID, &v.Version, &v.Title, &v.Description, &v.Answer, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return versions, nil
+}
@@ -21,18 +21,43 @@ func NewComplaintRepo(db *Storage) repository.ComplaintRepository {
 	return &complaintRepo{db: db.db}
 }
 
-func (c complaintRepo) SaveComplaint(ctx context.Context, barcode int, categoryID uuid.UUID, message string) (uuid.UUID, string, error) {
-	query := `INSERT INTO complaints (barcode, category_id, message) VALUES ($1, $2, $3) RETURNING uuid`
+func (c complaintRepo) SaveComplaint(ctx context.Context, barcode int, categoryID uuid.UUID, message string, review domain.ContentReview) (uuid.UUID, string, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return uuid.UUID{}, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if review.Flags == nil {
+		review.Flags = []string{}
+	}
+	// The answer comes from the category version the complaint is recorded against, so the
+	// student sees the same text an admin later finds on the complaint.
+	query := `
+		WITH v AS (
+			SELECT id, answer FROM category_versions WHERE category_id = $2 ORDER BY version DESC LIMIT 1
+		)
+		INSERT INTO complaints (barcode, category_id, submitted_category_id, message, content_flags, message_original, category_version_id)
+		VALUES ($1, $2, $2, $3, $4, $5, (SELECT id FROM v))
+		RETURNING uuid, COALESCE((SELECT answer FROM v), (SELECT answer FROM categories WHERE uuid = $2))`
 	var complaintID uuid.UUID
-	err := c.db.QueryRow(ctx, query, barcode, categoryID, message).Scan(&complaintID)
+	var answer string
+	err = tx.QueryRow(ctx, query, barcode, categoryID, message, review.Flags, review.SealedOriginal).Scan(&complaintID, &answer)
 	if err != nil {
 		return uuid.UUID{}, "", fmt.Errorf("failed to save complaint: %w", err)
 	}
 
-	var answer string
-	err = c.db.QueryRow(ctx, `SELECT answer FROM categories WHERE uuid = $1`, categoryID).Scan(&answer)
+	complaint, err := loadComplaint(ctx, tx, complaintID)
 	if err != nil {
-		return uuid.UUID{}, "", fmt.Errorf("failed to get categories answer: %w", err)
+		return uuid.UUID{}, "", fmt.Errorf("failed to load saved complaint: %w", err)
+	}
+
+	if err := writeOutbox(ctx, tx, domain.EventComplaintCreated, complaint); err != nil {
+		return uuid.UUID{}, "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.UUID{}, "", fmt.Errorf("failed to save complaint: %w", err)
 	}
 
 	return complaintID, answer, nil
@@ -54,14 +79,26 @@ func (c complaintRepo) IsOwnerOfComplaint(ctx context.Context, id uuid.UUID, bar
 func (c complaintRepo) GetComplaintByUUID(ctx context.Context, id uuid.UUID) (domain.Complaint, error) {
 	const op = "storage.postgres.GetComplaintByUUID"
 	query := `
-		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer,
-		       cat.uuid, cat.title, cat.description, cat.answer
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer, c.endorsements, c.content_flags, c.edited_at, c.version,
+		       cat.uuid, cat.title, cat.description, cat.answer,
+		       v.id, v.category_id, v.version, v.title, v.description, v.answer, v.created_at
 		FROM complaints c
 		JOIN categories cat ON c.category_id = cat.uuid
+		LEFT JOIN category_versions v ON v.id = c.category_version_id
 		WHERE c.uuid = $1`
 
 	var complaint domain.Complaint
 	var category domain.Category
+	// Complaints submitted before category versioning have no snapshot.
+	var (
+		versionID          *int64
+		versionCategoryID  *uuid.UUID
+		versionNumber      *int
+		versionTitle       *string
+		versionDescription *string
+		versionAnswer      *string
+		versionCreatedAt   *time.Time
+	)
 
 	err := c.db.QueryRow(ctx, query, id).Scan(
 		&complaint.ID,
@@ -70,10 +107,21 @@ func (c complaintRepo) GetComplaintByUUID(ctx context.Context, id uuid.UUID) (do
 		&complaint.Status,
 		&complaint.CreatedAt,
 		&complaint.Answer,
+		&complaint.Endorsements,
+		&complaint.ContentFlags,
+		&complaint.EditedAt,
+		&complaint.Version,
 		&category.ID,
 		&category.Title,
 		&category.Description,
 		&category.Answer,
+		&versionID,
+		&versionCategoryID,
+		&versionNumber,
+		&versionTitle,
+		&versionDescription,
+		&versionAnswer,
+		&versionCreatedAt,
 	)
 
 	if err != nil {
@@ -85,17 +133,36 @@ func (c complaintRepo) GetComplaintByUUID(ctx context.Context, id uuid.UUID) (do
 	}
 
 	complaint.Category = category
+	if versionID != nil {
+		complaint.CategoryVersion = &domain.CategoryVersion{
+			ID:          *versionID,
+			CategoryID:  *versionCategoryID,
+			Version:     *versionNumber,
+			Title:       *versionTitle,
+			Description: *versionDescription,
+			Answer:      *versionAnswer,
+			CreatedAt:   *versionCreatedAt,
+		}
+	}
 	return complaint, nil
 }
 
-func (c complaintRepo) GetComplaints(ctx context.Context) ([]domain.Complaint, error) {
+// orderBy is the ORDER BY clause of a complaint listing aliased c.
+func orderBy(order domain.ComplaintOrder) string {
+	if order == domain.OrderEndorsements {
+		return ` ORDER BY c.endorsements DESC, c.created_at DESC`
+	}
+	return ""
+}
+
+func (c complaintRepo) GetComplaints(ctx context.Context, order domain.ComplaintOrder) ([]domain.Complaint, error) {
 	const op = "storage.postgres.GetComplaints"
 
 	query := `
-		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer,
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer, c.endorsements, c.content_flags, c.edited_at, c.version,
 		       cat.uuid, cat.title, cat.description, cat.answer
 		FROM complaints c
-		JOIN categories cat ON c.category_id = cat.uuid`
+		JOIN categories cat ON c.category_id = cat.uuid` + orderBy(order)
 
 	rows, err := c.db.Query(ctx, query)
 	if err != nil {
@@ -115,6 +182,10 @@ func (c complaintRepo) GetComplaints(ctx context.Context) ([]domain.Complaint, e
 			&complaint.Status,
 			&complaint.CreatedAt,
 			&complaint.Answer,
+			&complaint.Endorsements,
+			&complaint.ContentFlags,
+			&complaint.EditedAt,
+			&complaint.Version,
 			&category.ID,
 			&category.Title,
 			&category.Description,
@@ -138,15 +209,15 @@ func (c complaintRepo) GetComplaints(ctx context.Context) ([]domain.Complaint, e
 	return complaints, nil
 }
 
-func (c complaintRepo) GetComplaintsByCategoryId(ctx context.Context, categoryId uuid.UUID) ([]domain.Complaint, error) {
+func (c complaintRepo) GetComplaintsByCategoryId(ctx context.Context, categoryId uuid.UUID, order domain.ComplaintOrder) ([]domain.Complaint, error) {
 	const op = "storage.postgres.GetComplaintsByCategory"
 
 	query := `
-		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer,
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.answer, c.endorsements, c.content_flags, c.edited_at, c.version,
 		       cat.uuid, cat.title, cat.description, cat.answer
 		FROM complaints c
 		JOIN categories cat ON c.category_id = cat.uuid
-		WHERE c.category_id = $1`
+		WHERE c.category_id = $1` + orderBy(order)
 
 	rows, err := c.db.Query(ctx, query, categoryId)
 	if err != nil {
@@ -166,6 +237,10 @@ func (c complaintRepo) GetComplaintsByCategoryId(ctx context.Context, categoryId
 			&complaint.Status,
 			&complaint.CreatedAt,
 			&complaint.Answer,
+			&complaint.Endorsements,
+			&complaint.ContentFlags,
+			&complaint.EditedAt,
+			&complaint.Version,
 			&category.ID,
 			&category.Title,
 			&category.Description,
@@ -188,7 +263,7 @@ func (c complaintRepo) GetComplaintsByCategoryId(ctx context.Context, categoryId
 func (c complaintRepo) GetComplaintsByBarcode(ctx context.Context, barcode int) ([]domain.Complaint, error) {
 	rows, err := c.db.Query(ctx, `
 	SELECT c.uuid, c.barcode, cat.uuid, cat.title, cat.description, cat.answer,
-		c.message, c.status, c.created_at, c.updated_at, c.answer FROM complaints c
+		c.message, c.status, c.created_at, c.updated_at, c.answer, c.endorsements, c.content_flags, c.edited_at, c.version FROM complaints c
 	JOIN categories cat ON cat.uuid = c.category_id WHERE c.barcode = $1`, barcode)
 	if err != nil {
 		fmt.Println("error:", err)
@@ -213,6 +288,10 @@ func (c complaintRepo) GetComplaintsByBarcode(ctx context.Context, barcode int)
 			&c.CreatedAt,
 			&c.UpdatedAt,
 			&c.Answer,
+			&c.Endorsements,
+			&c.ContentFlags,
+			&c.EditedAt,
+			&c.Version,
 		); err != nil {
 			fmt.Println("scan error:", err)
 			return nil, err
@@ -250,69 +329,71 @@ func (c complaintRepo) CheckComplaintLimit(ctx context.Context, barcode int) (bo
 	return true, nil
 }
 
-func (c complaintRepo) UpdateComplaintStatus(ctx context.Context, id uuid.UUID, status domain.ComplaintStatus, answer string) error {
-	const op = "storage.postgres.UpdateComplaintStatus"
+func (c complaintRepo) DeleteComplaint(ctx context.Context, id uuid.UUID, expectedVersion int) error {
+	const op = "storage.postgres.DeleteComplaintById"
 
-	var exists bool
-	err := c.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM complaints WHERE uuid = $1)", id).Scan(&exists)
+	tx, err := c.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if !exists {
-		// Если жалоба с таким ID не найдена
+	defer tx.Rollback(ctx)
+
+	complaint, err := loadComplaint(ctx, tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
 		return storage.ErrComplaintNotFound
 	}
-
-	query := `
-		UPDATE complaints
-		SET status = $1, updated_at = CURRENT_TIMESTAMP, answer = $3
-		WHERE uuid = $2`
-
-	result, err := c.db.Exec(ctx, query, status, id, answer)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected := result.RowsAffected()
-
-	if rowsAffected == 0 {
-		return storage.ErrComplaintNotFound
+	query := `DELETE FROM complaints WHERE uuid = $1 AND ($2 = 0 OR version = $2)`
+	r, err := tx.Exec(ctx, query, id, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-
-	return nil
-}
-
-func (c complaintRepo) DeleteComplaint(ctx context.Context, id uuid.UUID) error {
-	const op = "storage.postgres.DeleteComplaintById"
-
-	query := `DELETE FROM complaints WHERE uuid = $1`
-	r, err := c.db.Exec(ctx, query, id)
-	affected := r.RowsAffected()
-	if affected == 0 {
-		return storage.ErrComplaintNotFound
+	if r.RowsAffected() == 0 {
+		// The complaint was read above, so only the version can have missed.
+		return storage.ErrVersionMismatch
 	}
 
-	if err != nil {
+	if err := writeOutbox(ctx, tx, domain.EventComplaintDeleted, complaint); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
 
-func (c complaintRepo) UpdateComplaint(ctx context.Context, id uuid.UUID, complaint domain.Complaint) (domain.Complaint, error) {
+func (c complaintRepo) UpdateComplaint(ctx context.Context, id uuid.UUID, complaint domain.Complaint, actor int, expectedVersion int) (domain.Complaint, error) {
 	const op = "storage.postgres.UpdateComplaint"
 
-	var exists bool
-	err := c.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM complaints WHERE uuid = $1)", id).Scan(&exists)
+	tx, err := c.db.Begin(ctx)
 	if err != nil {
 		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
 	}
-	if !exists {
+	defer tx.Rollback(ctx)
+
+	var version int
+	var merged bool
+	err = tx.QueryRow(ctx, "SELECT version, merged_into IS NOT NULL FROM complaints WHERE uuid = $1 FOR UPDATE", id).Scan(&version, &merged)
+	if errors.Is(err, sql.ErrNoRows) {
 		return domain.Complaint{}, storage.ErrComplaintNotFound
 	}
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		return domain.Complaint{}, storage.ErrVersionMismatch
+	}
+	// A duplicate follows its primary, so a change of its own would be overwritten.
+	if merged {
+		return domain.Complaint{}, storage.ErrComplaintMerged
+	}
 
 	var categoryExists bool
-	err = c.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1)", complaint.Category.ID).Scan(&categoryExists)
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1)", complaint.Category.ID).Scan(&categoryExists)
 	if err != nil {
 		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -320,9 +401,12 @@ func (c complaintRepo) UpdateComplaint(ctx context.Context, id uuid.UUID, compla
 		return domain.Complaint{}, fmt.Errorf("categories with id %s does not exist", complaint.Category.ID.String())
 	}
 
-	_, err = c.db.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 		UPDATE complaints
-		SET barcode = $1, category_id = $2, message = $3, status = $4, answer = $5, updated_at = $6
+		SET barcode = $1, category_id = $2, message = $3, status = $4, answer = $5, updated_at = $6, version = version + 1,
+			first_answered_at = `+firstAnsweredAt("$5")+`,
+			resolved_at = `+resolvedAt("$4")+`,
+			resolved_by = `+resolvedBy("$4", "$8")+`
 		WHERE uuid = $7
 	`,
 		complaint.Barcode,
@@ -332,40 +416,41 @@ func (c complaintRepo) UpdateComplaint(ctx context.Context, id uuid.UUID, compla
 		complaint.Answer.String,
 		time.Now(),
 		id,
+		actor,
 	)
 	if err != nil {
 		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	query := `
-		SELECT 
-			c.uuid, c.barcode, c.category_id, c.message, c.status, c.answer, c.created_at, c.updated_at,
-			cat.title, cat.description, cat.answer
-		FROM complaints c
-		JOIN categories cat ON c.category_id = cat.uuid
-		WHERE c.uuid = $1
-	`
-
-	var updated domain.Complaint
-	var answer sql.NullString
-
-	err = c.db.QueryRow(ctx, query, id).Scan(
-		&updated.ID,
-		&updated.Barcode,
-		&updated.Category.ID,
-		&updated.Message,
-		&updated.Status,
-		&answer,
-		&updated.CreatedAt,
-		&updated.UpdatedAt,
-		&updated.Category.Title,
-		&updated.Category.Description,
-		&updated.Category.Answer,
-	)
+	updated, err := loadComplaint(ctx, tx, id)
 	if err != nil {
 		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	updated.Answer = answer
+	if err := writeOutbox(ctx, tx, domain.EventComplaintUpdated, updated); err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := followPrimary(ctx, tx, id); err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
 	return updated, nil
 }
+
+// firstAnsweredAt keeps the time the complaint got its first non-empty answer.
+func firstAnsweredAt(answerParam string) string {
+	return "CASE WHEN " + answerParam + " <> '' THEN COALESCE(first_answered_at, CURRENT_TIMESTAMP) ELSE first_answered_at END"
+}
+
+// resolvedAt stamps the time the complaint reached a final status and clears it when it is reopened.
+func resolvedAt(statusParam string) string {
+	return "CASE WHEN " + statusParam + " IN ('approved', 'rejected') THEN COALESCE(resolved_at, CURRENT_TIMESTAMP) ELSE NULL END"
+}
+
+// resolvedBy keeps the admin who first gave the complaint a final status, like resolvedAt keeps the time.
+func resolvedBy(statusParam string, actorParam string) string {
+	return "CASE WHEN " + statusParam + " IN ('approved', 'rejected') THEN CASE WHEN resolved_at IS NULL THEN " + actorParam + "::integer ELSE resolved_by END ELSE NULL END"
+}
@@ -0,0 +1,44 @@
+package pg
+
+import (
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (c complaintRepo) ReadOriginal(ctx context.Context, id uuid.UUID, actor int) ([]byte, []string, error) {
+	const op = "storage.postgres.ReadOriginal"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var sealed []byte
+	var flags []string
+	err = tx.QueryRow(ctx, `SELECT message_original, content_flags FROM complaints WHERE uuid = $1`, id).Scan(&sealed, &flags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if sealed == nil {
+		return nil, nil, storage.ErrNoOriginal
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO complaint_audit (complaint_id, actor, action)
+		VALUES ($1, $2, 'original_read')`, id, actor); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sealed, flags, nil
+}
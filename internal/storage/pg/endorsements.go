@@ -0,0 +1,74 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (c complaintRepo) SetEndorsement(ctx context.Context, id uuid.UUID, barcode int, endorse bool) (domain.Endorsement, error) {
+	const op = "storage.postgres.SetEndorsement"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.Endorsement{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Endorsing a merged duplicate backs its primary complaint.
+	var target uuid.UUID
+	var owner int
+	var public bool
+	err = tx.QueryRow(ctx, `
+		SELECT t.uuid, t.barcode, cat.is_public
+		FROM complaints c
+		JOIN complaints t ON t.uuid = COALESCE(c.merged_into, c.uuid)
+		JOIN categories cat ON cat.uuid = t.category_id
+		WHERE c.uuid = $1
+		FOR UPDATE OF t`, id,
+	).Scan(&target, &owner, &public)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Endorsement{}, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return domain.Endorsement{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !public {
+		return domain.Endorsement{}, storage.ErrNotPublic
+	}
+	if owner == barcode {
+		return domain.Endorsement{}, storage.ErrOwnComplaint
+	}
+
+	query := `INSERT INTO complaint_endorsements (complaint_id, barcode) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	delta := 1
+	if !endorse {
+		query = `DELETE FROM complaint_endorsements WHERE complaint_id = $1 AND barcode = $2`
+		delta = -1
+	}
+	tag, err := tx.Exec(ctx, query, target, barcode)
+	if err != nil {
+		return domain.Endorsement{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		delta = 0
+	}
+
+	result := domain.Endorsement{ComplaintID: target, Endorsed: endorse}
+	err = tx.QueryRow(ctx, `
+		UPDATE complaints SET endorsements = endorsements + $2 WHERE uuid = $1 RETURNING endorsements`,
+		target, delta,
+	).Scan(&result.Endorsements)
+	if err != nil {
+		return domain.Endorsement{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Endorsement{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
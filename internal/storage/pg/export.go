@@ -0,0 +1,100 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// complaintFilterWhere applies a domain.ComplaintFilter passed as $1..$4, see filterArgs.
+const complaintFilterWhere = `
+		WHERE ($1::text = '' OR c.status::text = $1)
+		  AND ($2::uuid IS NULL OR c.category_id = $2::uuid)
+		  AND ($3::timestamp IS NULL OR c.created_at >= $3::timestamp)
+		  AND ($4::timestamp IS NULL OR c.created_at < $4::timestamp)`
+
+func filterArgs(filter domain.ComplaintFilter) []any {
+	return []any{filter.Status, filter.CategoryID, filter.From, filter.To}
+}
+
+// ExportComplaints reads the complaints in batches from a cursor inside a read-only transaction,
+// so memory use doesn't grow with the size of the result.
+func (c complaintRepo) ExportComplaints(ctx context.Context, filter domain.ComplaintFilter, batchSize int, fn func(domain.Complaint) error) error {
+	const op = "storage.postgres.ExportComplaints"
+
+	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DECLARE export_cursor NO SCROLL CURSOR FOR
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.updated_at, c.answer,
+		       cat.uuid, cat.title, cat.description, cat.answer
+		FROM complaints c
+		JOIN categories cat ON c.category_id = cat.uuid`+complaintFilterWhere+`
+		ORDER BY c.created_at, c.uuid`,
+		filterArgs(filter)...,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", batchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			var complaint domain.Complaint
+			err := rows.Scan(
+				&complaint.ID,
+				&complaint.Barcode,
+				&complaint.Message,
+				&complaint.Status,
+				&complaint.CreatedAt,
+				&complaint.UpdatedAt,
+				&complaint.Answer,
+				&complaint.Category.ID,
+				&complaint.Category.Title,
+				&complaint.Category.Description,
+				&complaint.Category.Answer,
+			)
+			if err == nil {
+				err = fn(complaint)
+			}
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if fetched < batchSize {
+			return nil
+		}
+	}
+}
+
+func (c complaintRepo) CountComplaints(ctx context.Context, filter domain.ComplaintFilter) (int64, error) {
+	const op = "storage.postgres.CountComplaints"
+
+	var count int64
+	err := c.db.QueryRow(ctx, `
+		SELECT count(*)
+		FROM complaints c`+complaintFilterWhere,
+		filterArgs(filter)...,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
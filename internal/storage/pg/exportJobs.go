@@ -0,0 +1,177 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type exportJobRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewExportJobRepo(db *Storage) repository.ExportJobRepository {
+	return &exportJobRepo{db: db.db}
+}
+
+const exportJobColumns = `id, status, params, requested_by, total, rows, file_path, file_size, error, created_at, started_at, finished_at`
+
+func scanExportJob(row pgx.Row) (domain.ExportJob, error) {
+	var job domain.ExportJob
+	var params []byte
+	err := row.Scan(
+		&job.ID,
+		&job.Status,
+		&params,
+		&job.RequestedBy,
+		&job.Total,
+		&job.Rows,
+		&job.FilePath,
+		&job.FileSize,
+		&job.Error,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return domain.ExportJob{}, err
+	}
+	if err := json.Unmarshal(params, &job.Params); err != nil {
+		return domain.ExportJob{}, fmt.Errorf("failed to decode export params: %w", err)
+	}
+	return job, nil
+}
+
+func (e *exportJobRepo) Create(ctx context.Context, job domain.ExportJob) (uuid.UUID, error) {
+	const op = "storage.exportJobs.Create"
+
+	params, err := json.Marshal(job.Params)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id uuid.UUID
+	err = e.db.QueryRow(ctx, `INSERT INTO export_jobs (params, requested_by) VALUES ($1, $2) RETURNING id`,
+		params, job.RequestedBy,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (e *exportJobRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.ExportJob, error) {
+	const op = "storage.exportJobs.GetByID"
+
+	job, err := scanExportJob(e.db.QueryRow(ctx, `SELECT `+exportJobColumns+` FROM export_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ExportJob{}, storage.ErrExportJobNotFound
+	}
+	if err != nil {
+		return domain.ExportJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return job, nil
+}
+
+func (e *exportJobRepo) ClaimNext(ctx context.Context, lease time.Duration) (domain.ExportJob, error) {
+	const op = "storage.exportJobs.ClaimNext"
+
+	job, err := scanExportJob(e.db.QueryRow(ctx, `
+		UPDATE export_jobs j
+		SET status = 'running',
+		    started_at = CURRENT_TIMESTAMP,
+		    rows = 0,
+		    locked_until = CURRENT_TIMESTAMP + make_interval(secs => $1)
+		FROM (
+			SELECT id FROM export_jobs
+			WHERE status = 'queued'
+			   OR (status = 'running' AND locked_until < CURRENT_TIMESTAMP)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		) next
+		WHERE j.id = next.id
+		RETURNING j.id, j.status, j.params, j.requested_by, j.total, j.rows, j.file_path, j.file_size, j.error,
+		          j.created_at, j.started_at, j.finished_at`, lease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ExportJob{}, storage.ErrExportJobNotFound
+	}
+	if err != nil {
+		return domain.ExportJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return job, nil
+}
+
+func (e *exportJobRepo) UpdateProgress(ctx context.Context, id uuid.UUID, total int64, rows int64, lease time.Duration) error {
+	const op = "storage.exportJobs.UpdateProgress"
+
+	_, err := e.db.Exec(ctx, `
+		UPDATE export_jobs
+		SET total = $2, rows = $3, locked_until = CURRENT_TIMESTAMP + make_interval(secs => $4)
+		WHERE id = $1`, id, total, rows, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (e *exportJobRepo) MarkDone(ctx context.Context, id uuid.UUID, filePath string, fileSize int64, rows int64) error {
+	const op = "storage.exportJobs.MarkDone"
+
+	_, err := e.db.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'done', file_path = $2, file_size = $3, rows = $4,
+		    finished_at = CURRENT_TIMESTAMP, locked_until = NULL
+		WHERE id = $1`, id, filePath, fileSize, rows)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (e *exportJobRepo) MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error {
+	const op = "storage.exportJobs.MarkFailed"
+
+	_, err := e.db.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'failed', error = $2, finished_at = CURRENT_TIMESTAMP, locked_until = NULL
+		WHERE id = $1`, id, lastError)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (e *exportJobRepo) DeleteFinishedOlderThan(ctx context.Context, age time.Duration) ([]string, error) {
+	const op = "storage.exportJobs.DeleteFinishedOlderThan"
+
+	rows, err := e.db.Query(ctx, `
+		DELETE FROM export_jobs
+		WHERE status IN ('done', 'failed')
+		  AND finished_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+		RETURNING file_path`, age.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, rows.Err()
+}
@@ -0,0 +1,36 @@
+package pg
+
+import (
+	"complaint_server/internal/repository"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type notificationRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepo(db *Storage) repository.NotificationRepository {
+	return &notificationRepo{db: db.db}
+}
+
+func (n *notificationRepo) ClaimSend(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	const op = "storage.notifications.ClaimSend"
+
+	tag, err := n.db.Exec(ctx, `INSERT INTO notification_sends (event_id) VALUES ($1) ON CONFLICT DO NOTHING`, eventID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (n *notificationRepo) ReleaseSend(ctx context.Context, eventID uuid.UUID) error {
+	const op = "storage.notifications.ReleaseSend"
+
+	if _, err := n.db.Exec(ctx, `DELETE FROM notification_sends WHERE event_id = $1`, eventID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sort"
+	"time"
+)
+
+// querier is implemented by both *pgxpool.Pool and pgx.Tx, so helpers can run inside or outside a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type outboxRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepo(db *Storage) repository.OutboxRepository {
+	return &outboxRepo{db: db.db}
+}
+
+// writeOutbox records the event in the outbox. It must be called with the transaction
+// that performed the change, so the event is stored if and only if the change is committed.
+func writeOutbox(ctx context.Context, q querier, eventType domain.EventType, complaint domain.Complaint) error {
+	event := domain.NewEvent(eventType, complaint)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event: %w", err)
+	}
+
+	_, err = q.Exec(ctx, `
+		INSERT INTO outbox (event_id, event_type, aggregate_id, payload)
+		VALUES ($1, $2, $3, $4)`,
+		event.ID, event.Type, complaint.ID, payload,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// ClaimBatch locks the oldest unpublished messages with SKIP LOCKED and pushes their next attempt
+// forward by lease, so several dispatchers can run side by side without picking the same message.
+func (o *outboxRepo) ClaimBatch(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	const op = "storage.outbox.ClaimBatch"
+
+	query := `
+		WITH due AS (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox o
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		FROM due
+		WHERE o.id = due.id
+		RETURNING o.id, o.payload, o.attempts, o.created_at`
+
+	rows, err := o.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var messages []domain.OutboxMessage
+	for rows.Next() {
+		var message domain.OutboxMessage
+		var payload []byte
+		if err := rows.Scan(&message.ID, &payload, &message.Attempts, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := json.Unmarshal(payload, &message.Event); err != nil {
+			return nil, fmt.Errorf("%s: message %d: %w", op, message.ID, err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Claimed rows come back from UPDATE in no particular order.
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+func (o *outboxRepo) MarkPublished(ctx context.Context, id int64) error {
+	const op = "storage.outbox.MarkPublished"
+
+	_, err := o.db.Exec(ctx, `
+		UPDATE outbox SET published_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (o *outboxRepo) MarkFailed(ctx context.Context, id int64, attempts int, lastError string, retryIn time.Duration) error {
+	const op = "storage.outbox.MarkFailed"
+
+	_, err := o.db.Exec(ctx, `
+		UPDATE outbox
+		SET attempts = $1, last_error = $2, next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE id = $4`, attempts, lastError, retryIn.Seconds(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (o *outboxRepo) Stats(ctx context.Context) (domain.OutboxStats, error) {
+	const op = "storage.outbox.Stats"
+
+	var stats domain.OutboxStats
+	var oldestSeconds float64
+	err := o.db.QueryRow(ctx, `
+		SELECT count(*), COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - min(created_at))), 0)::float8
+		FROM outbox WHERE published_at IS NULL`,
+	).Scan(&stats.Backlog, &oldestSeconds)
+	if err != nil {
+		return domain.OutboxStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	stats.OldestAge = time.Duration(oldestSeconds * float64(time.Second))
+	return stats, nil
+}
+
+func (o *outboxRepo) DeletePublishedBefore(ctx context.Context, age time.Duration) (int64, error) {
+	const op = "storage.outbox.DeletePublishedBefore"
+
+	res, err := o.db.Exec(ctx, `
+		DELETE FROM outbox
+		WHERE published_at IS NOT NULL AND published_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`,
+		age.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// loadComplaint reads a complaint with its category through q, which may be a transaction.
+func loadComplaint(ctx context.Context, q querier, id uuid.UUID) (domain.Complaint, error) {
+	var complaint domain.Complaint
+	err := q.QueryRow(ctx, `
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.updated_at, c.answer, c.merged_into, c.endorsements, c.content_flags, c.edited_at, c.version,
+		       cat.uuid, cat.title, cat.description, cat.answer
+		FROM complaints c
+		JOIN categories cat ON c.category_id = cat.uuid
+		WHERE c.uuid = $1`, id,
+	).Scan(
+		&complaint.ID,
+		&complaint.Barcode,
+		&complaint.Message,
+		&complaint.Status,
+		&complaint.CreatedAt,
+		&complaint.UpdatedAt,
+		&complaint.Answer,
+		&complaint.MergedInto,
+		&complaint.Endorsements,
+		&complaint.ContentFlags,
+		&complaint.EditedAt,
+		&complaint.Version,
+		&complaint.Category.ID,
+		&complaint.Category.Title,
+		&complaint.Category.Description,
+		&complaint.Category.Answer,
+	)
+	return complaint, err
+}
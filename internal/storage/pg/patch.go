@@ -0,0 +1,227 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strconv"
+	"strings"
+)
+
+// setList builds the SET clause of an UPDATE from the columns that actually change.
+// Placeholders are numbered after the ones the caller reserves for its WHERE clause.
+type setList struct {
+	clauses []string
+	args    []any
+}
+
+func newSetList(reserved ...any) *setList {
+	return &setList{args: reserved}
+}
+
+// param adds value as an argument and returns its placeholder.
+func (s *setList) param(value any) string {
+	s.args = append(s.args, value)
+	return "$" + strconv.Itoa(len(s.args))
+}
+
+func (s *setList) set(column string, value any) {
+	s.clauses = append(s.clauses, column+" = "+s.param(value))
+}
+
+// expr sets a column to an SQL expression that may use placeholders from param.
+func (s *setList) expr(column string, expression string) {
+	s.clauses = append(s.clauses, column+" = "+expression)
+}
+
+func (s *setList) empty() bool {
+	return len(s.clauses) == 0
+}
+
+func (s *setList) String() string {
+	return strings.Join(s.clauses, ", ")
+}
+
+func (c complaintRepo) PatchComplaint(ctx context.Context, id uuid.UUID, patch domain.ComplaintPatch, actor int, expectedVersion int) (domain.Complaint, error) {
+	const op = "storage.postgres.PatchComplaint"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status domain.ComplaintStatus
+	var answer string
+	var categoryID uuid.UUID
+	var version int
+	var merged bool
+	err = tx.QueryRow(ctx, `
+		SELECT status, COALESCE(answer, ''), category_id, version, merged_into IS NOT NULL
+		FROM complaints
+		WHERE uuid = $1
+		FOR UPDATE`, id,
+	).Scan(&status, &answer, &categoryID, &version, &merged)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Complaint{}, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		return domain.Complaint{}, storage.ErrVersionMismatch
+	}
+	if merged {
+		return domain.Complaint{}, storage.ErrComplaintMerged
+	}
+
+	set := newSetList(id)
+	newStatus, newAnswer := status, answer
+	if patch.Status != nil && *patch.Status != status {
+		if err := domain.CheckTransition(status, *patch.Status); err != nil {
+			return domain.Complaint{}, err
+		}
+		newStatus = *patch.Status
+		p := set.param(newStatus)
+		set.expr("status", p+"::complaint_status")
+		set.expr("resolved_at", resolvedAt(p+"::complaint_status"))
+		set.expr("resolved_by", resolvedBy(p+"::complaint_status", set.param(actor)))
+	}
+	if patch.Answer != nil && *patch.Answer != answer {
+		newAnswer = *patch.Answer
+		p := set.param(newAnswer)
+		set.expr("answer", p)
+		set.expr("first_answered_at", firstAnsweredAt(p+"::text"))
+	}
+	if patch.CategoryID != nil && *patch.CategoryID != categoryID {
+		var active bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1 AND archived_at IS NULL)`, *patch.CategoryID).Scan(&active)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if !active {
+			return domain.Complaint{}, storage.ErrCategoryNotFound
+		}
+		set.set("category_id", *patch.CategoryID)
+	}
+
+	if !set.empty() {
+		set.expr("updated_at", "CURRENT_TIMESTAMP")
+		set.expr("version", "version + 1")
+		if _, err := tx.Exec(ctx, `UPDATE complaints SET `+set.String()+` WHERE uuid = $1`, set.args...); err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_audit (complaint_id, actor, action, from_status, to_status, answer)
+			VALUES ($1, $2, 'patch', $3, $4, $5)`,
+			id, actor, status, newStatus, newAnswer)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	complaint, err := loadComplaint(ctx, tx, id)
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !set.empty() {
+		eventType := domain.EventComplaintUpdated
+		if newStatus != status {
+			eventType = domain.EventComplaintStatusChanged
+		}
+		if err := writeOutbox(ctx, tx, eventType, complaint); err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := followPrimary(ctx, tx, id); err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return complaint, nil
+}
+
+func (c *categoryRepo) Patch(ctx context.Context, id uuid.UUID, patch domain.CategoryPatch, expectedVersion int) (domain.Category, error) {
+	const op = "storage.categories.Patch"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current domain.Category
+	err = tx.QueryRow(ctx, `
+		SELECT uuid, title, description, answer, archived_at IS NOT NULL, is_public, version
+		FROM categories
+		WHERE uuid = $1
+		FOR UPDATE`, id,
+	).Scan(&current.ID, &current.Title, &current.Description, &current.Answer, &current.Archived, &current.Public, &current.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Category{}, storage.ErrCategoryNotFound
+	}
+	if err != nil {
+		return domain.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if expectedVersion != 0 && current.Version != expectedVersion {
+		return domain.Category{}, storage.ErrVersionMismatch
+	}
+
+	set := newSetList(id)
+	updated := current
+	if patch.Title != nil && *patch.Title != current.Title {
+		updated.Title = *patch.Title
+		set.set("title", updated.Title)
+	}
+	if patch.Description != nil && *patch.Description != current.Description {
+		updated.Description = *patch.Description
+		set.set("description", updated.Description)
+	}
+	if patch.Answer != nil && *patch.Answer != current.Answer {
+		updated.Answer = *patch.Answer
+		set.set("answer", updated.Answer)
+	}
+	if patch.Public != nil && *patch.Public != current.Public {
+		updated.Public = *patch.Public
+		set.set("is_public", updated.Public)
+	}
+	if set.empty() {
+		return current, nil
+	}
+
+	set.expr("version", "version + 1")
+	_, err = tx.Exec(ctx, `UPDATE categories SET `+set.String()+` WHERE uuid = $1`, set.args...)
+	if isUniqueViolation(err) {
+		return domain.Category{}, storage.ErrCategoryTitleTaken
+	}
+	if err != nil {
+		return domain.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+	// The ETag version counts every change; the content history only changes of what students see.
+	contentChanged := updated.Title != current.Title || updated.Description != current.Description || updated.Answer != current.Answer
+	if contentChanged {
+		if err := recordCategoryVersion(ctx, tx, id); err != nil {
+			return domain.Category{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated.Version++
+	return updated, nil
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint, here the category title.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,28 @@
+package pg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetList(t *testing.T) {
+	set := newSetList("id")
+	if !set.empty() {
+		t.Fatal("a new set list isn't empty")
+	}
+
+	set.set("message", "Нет горячей воды")
+	set.expr("updated_at", "now()")
+	set.expr("category_id", "COALESCE("+set.param(3)+", category_id)")
+
+	if set.empty() {
+		t.Fatal("set list is empty after set")
+	}
+	// Placeholders continue after the reserved arguments.
+	if got, want := set.String(), "message = $2, updated_at = now(), category_id = COALESCE($3, category_id)"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if want := []any{"id", "Нет горячей воды", 3}; !slices.Equal(set.args, want) {
+		t.Errorf("args = %v, want %v", set.args, want)
+	}
+}
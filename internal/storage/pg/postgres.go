@@ -40,6 +40,12 @@ func New(connString string) (*Storage, error) {
 			answer TEXT NOT NULL
 		);`,
 
+		`ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;`,
+
+		`ALTER TABLE categories ADD COLUMN IF NOT EXISTS is_public BOOLEAN NOT NULL DEFAULT false;`,
+
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm;`,
+
 		`CREATE TABLE IF NOT EXISTS complaints (
 			uuid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			barcode INTEGER NOT NULL,
@@ -56,6 +62,217 @@ func New(connString string) (*Storage, error) {
 			id SERIAL PRIMARY KEY,
 			barcode INTEGER UNIQUE NOT NULL
 		);`,
+
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			uuid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			event_types TEXT[] NOT NULL,
+			category_id UUID,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (category_id) REFERENCES categories(uuid) ON DELETE CASCADE
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			uuid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			subscription_id UUID NOT NULL,
+			event_id UUID NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			response_code INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(uuid) ON DELETE CASCADE
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+			ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx
+			ON webhook_deliveries (subscription_id, event_id);`,
+
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id BIGSERIAL PRIMARY KEY,
+			event_id UUID NOT NULL UNIQUE,
+			event_type TEXT NOT NULL,
+			aggregate_id UUID NOT NULL,
+			payload JSONB NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			published_at TIMESTAMP
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS outbox_unpublished_idx
+			ON outbox (next_attempt_at) WHERE published_at IS NULL;`,
+
+		`ALTER TABLE complaints
+			ADD COLUMN IF NOT EXISTS first_answered_at TIMESTAMP,
+			ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;`,
+
+		`UPDATE complaints SET first_answered_at = updated_at
+			WHERE first_answered_at IS NULL AND answer IS NOT NULL AND answer <> '';`,
+
+		`UPDATE complaints SET resolved_at = updated_at
+			WHERE resolved_at IS NULL AND status IN ('approved', 'rejected');`,
+
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS complaint_daily_stats AS
+			SELECT created_at::date AS day, category_id, status, count(*) AS complaints
+			FROM complaints
+			GROUP BY 1, 2, 3;`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS complaint_daily_stats_key
+			ON complaint_daily_stats (day, category_id, status);`,
+
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS complaint_durations AS
+			SELECT uuid, category_id, created_at::date AS day, status,
+			       EXTRACT(EPOCH FROM (first_answered_at - created_at))::float8 AS first_answer_seconds,
+			       EXTRACT(EPOCH FROM (resolved_at - created_at))::float8 AS resolution_seconds
+			FROM complaints
+			WHERE first_answered_at IS NOT NULL OR resolved_at IS NOT NULL;`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS complaint_durations_key ON complaint_durations (uuid);`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES complaints(uuid) ON DELETE SET NULL;`,
+
+		`CREATE INDEX IF NOT EXISTS complaints_merged_into_idx ON complaints (merged_into) WHERE merged_into IS NOT NULL;`,
+
+		// Similar complaints are looked up within a category and a time window.
+		`CREATE INDEX IF NOT EXISTS complaints_category_created_idx ON complaints (category_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS complaints_message_trgm_idx ON complaints USING gin (message gin_trgm_ops);`,
+
+		// endorsements caches count(*) of complaint_endorsements so lists can be sorted by it.
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS endorsements INTEGER NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS hidden_from_board BOOLEAN NOT NULL DEFAULT false;`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS content_flags TEXT[] NOT NULL DEFAULT '{}';`,
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS message_original BYTEA;`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS submitted_category_id UUID;`,
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS suggested_category_id UUID;`,
+
+		`CREATE TABLE IF NOT EXISTS category_keywords (
+			category_id UUID NOT NULL REFERENCES categories(uuid) ON DELETE CASCADE,
+			keyword TEXT NOT NULL,
+			PRIMARY KEY (category_id, keyword)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS category_versions (
+			id BIGSERIAL PRIMARY KEY,
+			category_id UUID NOT NULL REFERENCES categories(uuid) ON DELETE CASCADE,
+			version INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL,
+			answer TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (category_id, version)
+		);`,
+
+		// Categories created before versioning start with their current content as version 1.
+		`INSERT INTO category_versions (category_id, version, title, description, answer)
+		SELECT c.uuid, 1, c.title, c.description, c.answer
+		FROM categories c
+		WHERE NOT EXISTS (SELECT 1 FROM category_versions v WHERE v.category_id = c.uuid);`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS category_version_id BIGINT REFERENCES category_versions(id);`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS resolved_by INTEGER;`,
+
+		`CREATE TABLE IF NOT EXISTS complaint_ratings (
+			complaint_id UUID PRIMARY KEY REFERENCES complaints(uuid) ON DELETE CASCADE,
+			barcode INTEGER NOT NULL,
+			rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+			comment TEXT NOT NULL DEFAULT '',
+			resolved BOOLEAN NOT NULL,
+			resolved_by INTEGER,
+			category_id UUID NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS complaint_ratings_created_idx ON complaint_ratings (created_at);`,
+
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;`,
+
+		`CREATE TABLE IF NOT EXISTS complaint_revisions (
+			complaint_id UUID NOT NULL REFERENCES complaints(uuid) ON DELETE CASCADE,
+			revision INTEGER NOT NULL,
+			message TEXT NOT NULL,
+			category_id UUID NOT NULL REFERENCES categories(uuid),
+			content_flags TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (complaint_id, revision)
+		);`,
+
+		// Row versions for If-Match. They change with every write an admin could overwrite.
+		`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`,
+		`ALTER TABLE categories ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`,
+
+		`CREATE TABLE IF NOT EXISTS answer_templates (
+			uuid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			category_id UUID REFERENCES categories(uuid) ON DELETE CASCADE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS complaint_endorsements (
+			complaint_id UUID NOT NULL REFERENCES complaints(uuid) ON DELETE CASCADE,
+			barcode INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (complaint_id, barcode)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS complaint_audit (
+			id BIGSERIAL PRIMARY KEY,
+			complaint_id UUID NOT NULL,
+			actor INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			from_status complaint_status,
+			to_status complaint_status,
+			answer TEXT NOT NULL DEFAULT '',
+			batch_id UUID,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS complaint_audit_complaint_idx ON complaint_audit (complaint_id, created_at);`,
+
+		`CREATE TABLE IF NOT EXISTS student_contacts (
+			barcode INTEGER PRIMARY KEY,
+			email TEXT NOT NULL,
+			locale TEXT NOT NULL DEFAULT 'ru'
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS notification_sends (
+			event_id UUID PRIMARY KEY,
+			sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS export_jobs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			status TEXT NOT NULL DEFAULT 'queued',
+			params JSONB NOT NULL,
+			requested_by INTEGER NOT NULL,
+			total BIGINT NOT NULL DEFAULT 0,
+			rows BIGINT NOT NULL DEFAULT 0,
+			file_path TEXT NOT NULL DEFAULT '',
+			file_size BIGINT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			locked_until TIMESTAMP
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS export_jobs_pending_idx ON export_jobs (created_at) WHERE status IN ('queued', 'running');`,
 	}
 
 	for _, stmt := range statements {
@@ -66,3 +283,8 @@ func New(connString string) (*Storage, error) {
 
 	return &Storage{db: pool}, nil
 }
+
+// Ping checks that Postgres answers.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
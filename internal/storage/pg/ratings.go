@@ -0,0 +1,101 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+func (c complaintRepo) RateComplaint(ctx context.Context, rating domain.Rating, barcode int, window time.Duration) (domain.Rating, error) {
+	const op = "storage.postgres.RateComplaint"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Only approved complaints can be rated; a rejected one has nothing to rate. A "not resolved"
+	// answer on a merged duplicate reopens its primary complaint.
+	var owner int
+	var approved, inWindow bool
+	var target uuid.UUID
+	var targetStatus domain.ComplaintStatus
+	err = tx.QueryRow(ctx, `
+		SELECT c.barcode, c.status = 'approved' AND c.resolved_at IS NOT NULL,
+		       COALESCE(CURRENT_TIMESTAMP - c.resolved_at <= make_interval(secs => $2), false),
+		       t.uuid, t.status
+		FROM complaints c
+		JOIN complaints t ON t.uuid = COALESCE(c.merged_into, c.uuid)
+		WHERE c.uuid = $1
+		FOR UPDATE OF c, t`, rating.ComplaintID, window.Seconds(),
+	).Scan(&owner, &approved, &inWindow, &target, &targetStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Rating{}, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case owner != barcode:
+		return domain.Rating{}, storage.ErrNotComplaintOwner
+	case !approved:
+		return domain.Rating{}, storage.ErrNotResolved
+	case !inWindow:
+		return domain.Rating{}, storage.ErrRatingWindowClosed
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO complaint_ratings (complaint_id, barcode, rating, comment, resolved, resolved_by, category_id)
+		SELECT uuid, barcode, $2, $3, $4, resolved_by, category_id FROM complaints WHERE uuid = $1
+		ON CONFLICT (complaint_id) DO NOTHING
+		RETURNING created_at`,
+		rating.ComplaintID, rating.Rating, rating.Comment, rating.Resolved,
+	).Scan(&rating.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Rating{}, storage.ErrAlreadyRated
+	}
+	if err != nil {
+		return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !rating.Resolved && targetStatus != domain.StatusPending {
+		_, err = tx.Exec(ctx, `
+			UPDATE complaints
+			SET status = 'pending', updated_at = CURRENT_TIMESTAMP, resolved_at = NULL, resolved_by = NULL, version = version + 1
+			WHERE uuid = $1`, target)
+		if err != nil {
+			return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_audit (complaint_id, actor, action, from_status, to_status, answer)
+			VALUES ($1, $2, 'reopened_by_student', $3, 'pending', $4)`,
+			target, barcode, targetStatus, rating.Comment)
+		if err != nil {
+			return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		complaint, err := loadComplaint(ctx, tx, target)
+		if err != nil {
+			return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := writeOutbox(ctx, tx, domain.EventComplaintStatusChanged, complaint); err != nil {
+			return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := followPrimary(ctx, tx, target); err != nil {
+			return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+		}
+		rating.Reopened = true
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Rating{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return rating, nil
+}
@@ -0,0 +1,166 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"slices"
+)
+
+func (c complaintRepo) EditComplaint(ctx context.Context, id uuid.UUID, barcode int, edit domain.ComplaintEdit, review domain.ContentReview) (domain.Complaint, error) {
+	const op = "storage.postgres.EditComplaint"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// An answer or a merge means an admin has started on the complaint even if it is still pending.
+	var owner int
+	var locked bool
+	var message string
+	var categoryID uuid.UUID
+	var flags []string
+	err = tx.QueryRow(ctx, `
+		SELECT barcode, status <> 'pending' OR first_answered_at IS NOT NULL OR merged_into IS NOT NULL,
+		       message, category_id, content_flags
+		FROM complaints
+		WHERE uuid = $1
+		FOR UPDATE`, id,
+	).Scan(&owner, &locked, &message, &categoryID, &flags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Complaint{}, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case owner != barcode:
+		return domain.Complaint{}, storage.ErrNotComplaintOwner
+	case locked:
+		return domain.Complaint{}, storage.ErrComplaintLocked
+	}
+
+	newMessage, newCategoryID, newFlags := message, categoryID, flags
+	if edit.Message != nil {
+		newMessage = *edit.Message
+		newFlags = review.Flags
+		if newFlags == nil {
+			newFlags = []string{}
+		}
+	}
+	if edit.CategoryID != nil && *edit.CategoryID != categoryID {
+		var active bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1 AND archived_at IS NULL)`, *edit.CategoryID).Scan(&active)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if !active {
+			return domain.Complaint{}, storage.ErrCategoryNotFound
+		}
+		newCategoryID = *edit.CategoryID
+	}
+
+	changed := newMessage != message || newCategoryID != categoryID || !slices.Equal(newFlags, flags)
+	if changed {
+		// The content as submitted becomes revision 1 on the first edit.
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_revisions (complaint_id, revision, message, category_id, content_flags, created_at)
+			SELECT uuid, 1, message, category_id, content_flags, created_at FROM complaints WHERE uuid = $1
+			ON CONFLICT DO NOTHING`, id)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_revisions (complaint_id, revision, message, category_id, content_flags)
+			SELECT $1, max(revision) + 1, $2, $3, $4 FROM complaint_revisions WHERE complaint_id = $1`,
+			id, newMessage, newCategoryID, newFlags)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// A student who picks the category again sets what counts as submitted for suggestion
+		// accuracy; a message-only edit leaves it alone. The original of an earlier masked message
+		// is dropped with the message.
+		_, err = tx.Exec(ctx, `
+			UPDATE complaints
+			SET message = $2, content_flags = $4, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP, version = version + 1,
+			    message_original = CASE WHEN $5 THEN $6 ELSE message_original END,
+			    category_id = $3,
+			    submitted_category_id = CASE WHEN $7 THEN $3 ELSE submitted_category_id END,
+			    category_version_id = CASE WHEN category_id = $3 THEN category_version_id ELSE (
+			        SELECT id FROM category_versions WHERE category_id = $3 ORDER BY version DESC LIMIT 1
+			    ) END
+			WHERE uuid = $1`,
+			id, newMessage, newCategoryID, newFlags, edit.Message != nil, review.SealedOriginal, edit.CategoryID != nil)
+		if err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	complaint, err := loadComplaint(ctx, tx, id)
+	if err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if changed {
+		if err := writeOutbox(ctx, tx, domain.EventComplaintUpdated, complaint); err != nil {
+			return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Complaint{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return complaint, nil
+}
+
+func (c complaintRepo) GetRevisions(ctx context.Context, id uuid.UUID) ([]domain.ComplaintRevision, error) {
+	const op = "storage.postgres.GetRevisions"
+
+	// Complaints that were never edited have no rows, so their current content stands in as revision 1.
+	rows, err := c.db.Query(ctx, `
+		SELECT r.revision, r.message, r.category_id, cat.title, r.content_flags, r.created_at
+		FROM complaint_revisions r
+		JOIN categories cat ON cat.uuid = r.category_id
+		WHERE r.complaint_id = $1
+		UNION ALL
+		SELECT 1, c.message, c.category_id, cat.title, c.content_flags, c.created_at
+		FROM complaints c
+		JOIN categories cat ON cat.uuid = c.category_id
+		WHERE c.uuid = $1
+		  AND NOT EXISTS (SELECT 1 FROM complaint_revisions WHERE complaint_id = $1)
+		ORDER BY 1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []domain.ComplaintRevision
+	for rows.Next() {
+		var revision domain.ComplaintRevision
+		err := rows.Scan(
+			&revision.Revision,
+			&revision.Message,
+			&revision.CategoryID,
+			&revision.Category,
+			&revision.ContentFlags,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(revisions) == 0 {
+		return nil, storage.ErrComplaintNotFound
+	}
+	return revisions, nil
+}
@@ -0,0 +1,291 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+)
+
+// similarTo matches complaint c against a reference message t_message of category t_category
+// created at t_created. Parameters: $2 window seconds, $3 similarity threshold, $4 full-text flag.
+// It runs in a similarityTx, where % can use the trigram index on message.
+const similarTo = `
+	c.category_id = t_category
+	AND c.created_at BETWEEN t_created - make_interval(secs => $2) AND t_created + make_interval(secs => $2)
+	AND (
+		(c.message % t_message AND similarity(c.message, t_message) >= $3)
+		OR ($4 AND (
+			to_tsvector('simple', c.message) @@ plainto_tsquery('simple', t_message)
+			OR to_tsvector('simple', t_message) @@ plainto_tsquery('simple', c.message)
+		))
+	)`
+
+// similarityTx starts a transaction in which the % operator matches at threshold instead of the
+// server default. The caller rolls it back when done.
+func (c complaintRepo) similarityTx(ctx context.Context, threshold float64) (pgx.Tx, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(threshold, 'f', -1, 64))
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (c complaintRepo) FindSimilar(ctx context.Context, id uuid.UUID, opts domain.SimilarityOptions) ([]domain.SimilarComplaint, error) {
+	const op = "storage.postgres.FindSimilar"
+
+	tx, err := c.similarityTx(ctx, opts.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM complaints WHERE uuid = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return nil, storage.ErrComplaintNotFound
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH target AS (
+			SELECT uuid AS t_id, category_id AS t_category, message AS t_message, created_at AS t_created
+			FROM complaints WHERE uuid = $1
+		)
+		SELECT c.uuid, c.barcode, c.message, c.status, c.created_at, c.updated_at, c.answer, c.merged_into,
+		       cat.uuid, cat.title, cat.description, cat.answer,
+		       similarity(c.message, t_message) AS score,
+		       $4 AND to_tsvector('simple', c.message) @@ plainto_tsquery('simple', t_message) AS text_match
+		FROM complaints c
+		JOIN categories cat ON c.category_id = cat.uuid
+		CROSS JOIN target
+		WHERE c.uuid <> t_id AND `+similarTo+`
+		ORDER BY score DESC, c.created_at
+		LIMIT $5`,
+		id, opts.Window.Seconds(), opts.Threshold, opts.FullText, opts.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	similar := []domain.SimilarComplaint{}
+	for rows.Next() {
+		var s domain.SimilarComplaint
+		err := rows.Scan(
+			&s.Complaint.ID,
+			&s.Complaint.Barcode,
+			&s.Complaint.Message,
+			&s.Complaint.Status,
+			&s.Complaint.CreatedAt,
+			&s.Complaint.UpdatedAt,
+			&s.Complaint.Answer,
+			&s.Complaint.MergedInto,
+			&s.Complaint.Category.ID,
+			&s.Complaint.Category.Title,
+			&s.Complaint.Category.Description,
+			&s.Complaint.Category.Answer,
+			&s.Similarity,
+			&s.TextMatch,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		similar = append(similar, s)
+	}
+	return similar, rows.Err()
+}
+
+func (c complaintRepo) CountSimilarToText(ctx context.Context, categoryID uuid.UUID, message string, opts domain.SimilarityOptions) (int, error) {
+	const op = "storage.postgres.CountSimilarToText"
+
+	tx, err := c.similarityTx(ctx, opts.Threshold)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	err = tx.QueryRow(ctx, `
+		WITH target AS (
+			SELECT $1::uuid AS t_category, $5::text AS t_message, CURRENT_TIMESTAMP::timestamp AS t_created
+		)
+		SELECT count(*)
+		FROM complaints c
+		CROSS JOIN target
+		WHERE c.merged_into IS NULL AND `+similarTo,
+		categoryID, opts.Window.Seconds(), opts.Threshold, opts.FullText, message,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+func (c complaintRepo) Merge(ctx context.Context, primaryID uuid.UUID, duplicateIDs []uuid.UUID, actor int) (domain.BulkResult, error) {
+	const op = "storage.postgres.Merge"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var primaryMergedInto *uuid.UUID
+	var to domain.ComplaintStatus
+	var answer string
+	err = tx.QueryRow(ctx, `SELECT merged_into, status, COALESCE(answer, '') FROM complaints WHERE uuid = $1 FOR UPDATE`, primaryID).
+		Scan(&primaryMergedInto, &to, &answer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.BulkResult{}, storage.ErrComplaintNotFound
+	}
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if primaryMergedInto != nil {
+		return domain.BulkResult{}, storage.ErrPrimaryIsMerged
+	}
+
+	rows, err := tx.Query(ctx, `SELECT uuid, status FROM complaints WHERE uuid = ANY($1) FOR UPDATE`, duplicateIDs)
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	current := make(map[uuid.UUID]domain.ComplaintStatus)
+	for rows.Next() {
+		var id uuid.UUID
+		var status domain.ComplaintStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			rows.Close()
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		current[id] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := domain.BulkResult{BatchID: uuid.New(), Items: make([]domain.BulkItemResult, 0, len(duplicateIDs))}
+	for _, id := range duplicateIDs {
+		from, ok := current[id]
+		switch {
+		case !ok:
+			result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkNotFound})
+			result.NotFound++
+			continue
+		case id == primaryID:
+			result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkSkipped, FromStatus: from, Message: "a complaint can't be merged into itself"})
+			result.Skipped++
+			continue
+		}
+
+		// Complaints already merged into the duplicate move to the new primary, so chains stay one level deep.
+		_, err = tx.Exec(ctx, `UPDATE complaints SET merged_into = $1, version = version + 1 WHERE merged_into = $2`, primaryID, id)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = tx.Exec(ctx, `UPDATE complaints SET merged_into = $1, endorsements = 0, version = version + 1 WHERE uuid = $2`, primaryID, id)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		// Endorsements of the duplicate now back the primary, except its owner's own.
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_endorsements (complaint_id, barcode, created_at)
+			SELECT p.uuid, e.barcode, e.created_at
+			FROM complaint_endorsements e
+			JOIN complaints p ON p.uuid = $1
+			WHERE e.complaint_id = $2 AND e.barcode <> p.barcode
+			ON CONFLICT DO NOTHING`, primaryID, id)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM complaint_endorsements WHERE complaint_id = $1`, id)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO complaint_audit (complaint_id, actor, action, from_status, to_status, answer, batch_id)
+			VALUES ($1, $2, 'merge', $3, $4, $5, $6)`,
+			id, actor, from, to, answer, result.BatchID)
+		if err != nil {
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		result.Items = append(result.Items, domain.BulkItemResult{ID: id, Outcome: domain.BulkMerged, FromStatus: from})
+		result.Updated++
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE complaints
+		SET endorsements = (SELECT count(*) FROM complaint_endorsements WHERE complaint_id = $1)
+		WHERE uuid = $1`, primaryID)
+	if err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := followPrimary(ctx, tx, primaryID); err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// followPrimary copies the status and answer of a primary complaint to the complaints merged
+// into it and records a status change event for each one that changed.
+func followPrimary(ctx context.Context, q querier, primaryID uuid.UUID) error {
+	rows, err := q.Query(ctx, `
+		UPDATE complaints d
+		SET status = p.status,
+		    answer = p.answer,
+		    updated_at = CURRENT_TIMESTAMP,
+		    version = d.version + 1,
+		    first_answered_at = CASE WHEN COALESCE(p.answer, '') <> '' THEN COALESCE(d.first_answered_at, CURRENT_TIMESTAMP) ELSE d.first_answered_at END,
+		    resolved_at = CASE WHEN p.status IN ('approved', 'rejected') THEN COALESCE(d.resolved_at, CURRENT_TIMESTAMP) ELSE NULL END,
+		    resolved_by = p.resolved_by
+		FROM complaints p
+		WHERE p.uuid = $1
+		  AND d.merged_into = p.uuid
+		  AND (d.status IS DISTINCT FROM p.status OR d.answer IS DISTINCT FROM p.answer)
+		RETURNING d.uuid`, primaryID)
+	if err != nil {
+		return fmt.Errorf("failed to update merged complaints: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		complaint, err := loadComplaint(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if err := writeOutbox(ctx, q, domain.EventComplaintStatusChanged, complaint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
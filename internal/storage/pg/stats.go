@@ -0,0 +1,229 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// statsRefreshLock is the advisory lock key that lets only one replica refresh the views at a time.
+const statsRefreshLock = 31_0001
+
+type statsRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewStatsRepo(db *Storage) repository.StatsRepository {
+	return &statsRepo{db: db.db}
+}
+
+// statsWhere filters complaint_daily_stats and complaint_durations, both of which have day and category_id.
+const statsWhere = `
+	WHERE ($1::date IS NULL OR day >= $1::date)
+	  AND ($2::date IS NULL OR day < $2::date)
+	  AND ($3::uuid IS NULL OR category_id = $3::uuid)`
+
+// ratingsWhere filters complaint_ratings by the day the rating was given.
+const ratingsWhere = `
+	WHERE ($1::date IS NULL OR r.created_at >= $1::date)
+	  AND ($2::date IS NULL OR r.created_at < $2::date)
+	  AND ($3::uuid IS NULL OR r.category_id = $3::uuid)`
+
+// csatColumns aggregates complaint_ratings r into the fields of domain.CSAT.
+const csatColumns = `
+	count(*),
+	COALESCE(avg(r.rating), 0)::float8,
+	COALESCE(avg(CASE WHEN r.rating >= 4 THEN 1 ELSE 0 END), 0)::float8,
+	count(*) FILTER (WHERE NOT r.resolved)`
+
+func (s *statsRepo) GetStats(ctx context.Context, filter domain.StatsFilter) (domain.ComplaintStats, error) {
+	const op = "storage.stats.GetStats"
+
+	args := []any{filter.From, filter.To, filter.CategoryID}
+	stats := domain.ComplaintStats{
+		ByStatus: make(map[string]int64),
+		Interval: filter.Interval,
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT status::text, sum(complaints)::bigint
+		FROM complaint_daily_stats`+statsWhere+`
+		GROUP BY status`, args...)
+	if err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = s.db.Query(ctx, `
+		SELECT s.category_id, cat.title, sum(s.complaints)::bigint
+		FROM complaint_daily_stats s
+		JOIN categories cat ON cat.uuid = s.category_id`+statsWhere+`
+		GROUP BY s.category_id, cat.title
+		ORDER BY 3 DESC`, args...)
+	if err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	for rows.Next() {
+		var count domain.CategoryCount
+		if err := rows.Scan(&count.CategoryID, &count.Title, &count.Count); err != nil {
+			rows.Close()
+			return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.ByCategory = append(stats.ByCategory, count)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = s.db.Query(ctx, `
+		SELECT date_trunc($4, day)::timestamp AS period, sum(complaints)::bigint
+		FROM complaint_daily_stats`+statsWhere+`
+		GROUP BY period
+		ORDER BY period`, append(args, string(filter.Interval))...)
+	if err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	for rows.Next() {
+		var point domain.SeriesPoint
+		if err := rows.Scan(&point.Period, &point.Count); err != nil {
+			rows.Close()
+			return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.Series = append(stats.Series, point)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.db.QueryRow(ctx, `
+		SELECT
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY first_answer_seconds), 0),
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY first_answer_seconds), 0),
+			count(first_answer_seconds),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY resolution_seconds), 0),
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY resolution_seconds), 0),
+			count(resolution_seconds)
+		FROM complaint_durations`+statsWhere, args...,
+	).Scan(
+		&stats.TimeToFirstAnswer.MedianSeconds,
+		&stats.TimeToFirstAnswer.P90Seconds,
+		&stats.TimeToFirstAnswer.Samples,
+		&stats.TimeToResolution.MedianSeconds,
+		&stats.TimeToResolution.P90Seconds,
+		&stats.TimeToResolution.Samples,
+	)
+	if err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.csat(ctx, args, &stats); err != nil {
+		return domain.ComplaintStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if decided := stats.ByStatus["approved"] + stats.ByStatus["rejected"]; decided > 0 {
+		stats.ApprovalRatio = float64(stats.ByStatus["approved"]) / float64(decided)
+		stats.RejectionRatio = float64(stats.ByStatus["rejected"]) / float64(decided)
+	}
+
+	return stats, nil
+}
+
+// csat reads satisfaction ratings live from complaint_ratings; they are few compared to complaints.
+func (s *statsRepo) csat(ctx context.Context, args []any, stats *domain.ComplaintStats) error {
+	err := s.db.QueryRow(ctx, `SELECT`+csatColumns+` FROM complaint_ratings r`+ratingsWhere, args...).Scan(
+		&stats.CSAT.Responses,
+		&stats.CSAT.Average,
+		&stats.CSAT.SatisfiedRate,
+		&stats.CSAT.NotResolved,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT r.category_id, cat.title,`+csatColumns+`
+		FROM complaint_ratings r
+		JOIN categories cat ON cat.uuid = r.category_id`+ratingsWhere+`
+		GROUP BY r.category_id, cat.title
+		ORDER BY 4`, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var item domain.CategoryCSAT
+		if err := rows.Scan(&item.CategoryID, &item.Title, &item.Responses, &item.Average, &item.SatisfiedRate, &item.NotResolved); err != nil {
+			rows.Close()
+			return err
+		}
+		stats.CSATByCategory = append(stats.CSATByCategory, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Ratings of complaints resolved before resolved_by was recorded have no admin and are left out.
+	rows, err = s.db.Query(ctx, `
+		SELECT r.resolved_by,`+csatColumns+`
+		FROM complaint_ratings r`+ratingsWhere+`
+		  AND r.resolved_by IS NOT NULL
+		GROUP BY r.resolved_by
+		ORDER BY 3`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item domain.AdminCSAT
+		if err := rows.Scan(&item.Barcode, &item.Responses, &item.Average, &item.SatisfiedRate, &item.NotResolved); err != nil {
+			return err
+		}
+		stats.CSATByAdmin = append(stats.CSATByAdmin, item)
+	}
+	return rows.Err()
+}
+
+// Refresh rebuilds the statistics views. It returns false without refreshing
+// when another replica holds the refresh lock.
+func (s *statsRepo) Refresh(ctx context.Context) (bool, error) {
+	const op = "storage.stats.Refresh"
+
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, statsRefreshLock).Scan(&locked); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, statsRefreshLock)
+
+	for _, view := range []string{"complaint_daily_stats", "complaint_durations"} {
+		if _, err := conn.Exec(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			return false, fmt.Errorf("%s: %s: %w", op, view, err)
+		}
+	}
+	return true, nil
+}
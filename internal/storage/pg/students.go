@@ -0,0 +1,35 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type studentRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewStudentRepo(db *Storage) repository.StudentRepository {
+	return &studentRepo{db: db.db}
+}
+
+func (s *studentRepo) GetContact(ctx context.Context, barcode int) (domain.StudentContact, error) {
+	const op = "storage.students.GetContact"
+
+	var contact domain.StudentContact
+	err := s.db.QueryRow(ctx, `SELECT barcode, email, locale FROM student_contacts WHERE barcode = $1`, barcode).
+		Scan(&contact.Barcode, &contact.Email, &contact.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.StudentContact{}, storage.ErrStudentNotFound
+	}
+	if err != nil {
+		return domain.StudentContact{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return contact, nil
+}
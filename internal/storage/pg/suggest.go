@@ -0,0 +1,153 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type suggestRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSuggestRepo(db *Storage) repository.SuggestRepository {
+	return &suggestRepo{db: db.db}
+}
+
+// TrainingSamples returns the newest complaints since the given time. Merged duplicates and
+// complaints of archived categories are left out.
+func (s *suggestRepo) TrainingSamples(ctx context.Context, since time.Time, limit int) ([]domain.TrainingSample, error) {
+	const op = "storage.suggest.TrainingSamples"
+
+	rows, err := s.db.Query(ctx, `
+		SELECT c.category_id, c.message
+		FROM complaints c
+		JOIN categories cat ON cat.uuid = c.category_id
+		WHERE c.created_at >= $1
+		  AND c.merged_into IS NULL
+		  AND cat.archived_at IS NULL
+		ORDER BY c.created_at DESC
+		LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var samples []domain.TrainingSample
+	for rows.Next() {
+		var sample domain.TrainingSample
+		if err := rows.Scan(&sample.CategoryID, &sample.Message); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return samples, nil
+}
+
+func (s *suggestRepo) KeywordRules(ctx context.Context) ([]domain.KeywordRule, error) {
+	const op = "storage.suggest.KeywordRules"
+
+	rows, err := s.db.Query(ctx, `
+		SELECT k.category_id, array_agg(k.keyword ORDER BY k.keyword)
+		FROM category_keywords k
+		JOIN categories cat ON cat.uuid = k.category_id
+		WHERE cat.archived_at IS NULL
+		GROUP BY k.category_id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var rules []domain.KeywordRule
+	for rows.Next() {
+		var rule domain.KeywordRule
+		if err := rows.Scan(&rule.CategoryID, &rule.Keywords); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rules, nil
+}
+
+// SetKeywords replaces the keyword rules of a category.
+func (s *suggestRepo) SetKeywords(ctx context.Context, categoryID uuid.UUID, keywords []string) error {
+	const op = "storage.suggest.SetKeywords"
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE uuid = $1)`, categoryID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return storage.ErrCategoryNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM category_keywords WHERE category_id = $1`, categoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO category_keywords (category_id, keyword)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING`, categoryID, keywords); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *suggestRepo) RecordSuggestion(ctx context.Context, complaintID uuid.UUID, categoryID uuid.UUID) error {
+	const op = "storage.suggest.RecordSuggestion"
+
+	if _, err := s.db.Exec(ctx, `UPDATE complaints SET suggested_category_id = $2 WHERE uuid = $1`, complaintID, categoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *suggestRepo) Accuracy(ctx context.Context, from *time.Time, to *time.Time) (domain.SuggestionAccuracy, error) {
+	const op = "storage.suggest.Accuracy"
+
+	var acc domain.SuggestionAccuracy
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			count(*) FILTER (WHERE suggested_category_id IS NOT NULL),
+			count(*) FILTER (WHERE suggested_category_id = category_id),
+			count(*) FILTER (WHERE submitted_category_id <> category_id),
+			count(*) FILTER (WHERE submitted_category_id <> category_id AND suggested_category_id = category_id)
+		FROM complaints
+		WHERE submitted_category_id IS NOT NULL
+		  AND merged_into IS NULL
+		  AND ($1::timestamp IS NULL OR created_at >= $1::timestamp)
+		  AND ($2::timestamp IS NULL OR created_at < $2::timestamp)`, from, to,
+	).Scan(&acc.Suggested, &acc.Correct, &acc.Corrected, &acc.CorrectedPredicted)
+	if err != nil {
+		return domain.SuggestionAccuracy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if acc.Suggested > 0 {
+		acc.Accuracy = float64(acc.Correct) / float64(acc.Suggested)
+	}
+	if acc.Corrected > 0 {
+		acc.CorrectionRecall = float64(acc.CorrectedPredicted) / float64(acc.Corrected)
+	}
+	return acc, nil
+}
@@ -0,0 +1,140 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type templateRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepo(db *Storage) repository.AnswerTemplateRepository {
+	return &templateRepo{db: db.db}
+}
+
+const templateColumns = `uuid, title, body, category_id, created_at, updated_at`
+
+func (t *templateRepo) Create(ctx context.Context, template domain.AnswerTemplate) (uuid.UUID, error) {
+	const op = "storage.templates.Create"
+
+	var id uuid.UUID
+	err := t.db.QueryRow(ctx, `
+		INSERT INTO answer_templates (title, body, category_id)
+		VALUES ($1, $2, $3) RETURNING uuid`,
+		template.Title, template.Body, template.CategoryID,
+	).Scan(&id)
+	if isForeignKeyViolation(err) {
+		return uuid.Nil, storage.ErrCategoryNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (t *templateRepo) GetAll(ctx context.Context, categoryID *uuid.UUID) ([]domain.AnswerTemplate, error) {
+	const op = "storage.templates.GetAll"
+
+	query := `SELECT ` + templateColumns + ` FROM answer_templates ORDER BY category_id NULLS FIRST, title`
+	var args []any
+	if categoryID != nil {
+		query = `SELECT ` + templateColumns + ` FROM answer_templates
+			WHERE category_id IS NULL OR category_id = $1
+			ORDER BY category_id NULLS FIRST, title`
+		args = append(args, *categoryID)
+	}
+
+	rows, err := t.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var templates []domain.AnswerTemplate
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return templates, nil
+}
+
+func (t *templateRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.AnswerTemplate, error) {
+	const op = "storage.templates.GetByID"
+
+	template, err := scanTemplate(t.db.QueryRow(ctx, `SELECT `+templateColumns+` FROM answer_templates WHERE uuid = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.AnswerTemplate{}, storage.ErrTemplateNotFound
+	}
+	if err != nil {
+		return domain.AnswerTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return template, nil
+}
+
+func (t *templateRepo) Update(ctx context.Context, id uuid.UUID, template domain.AnswerTemplate) error {
+	const op = "storage.templates.Update"
+
+	res, err := t.db.Exec(ctx, `
+		UPDATE answer_templates
+		SET title = $1, body = $2, category_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE uuid = $4`,
+		template.Title, template.Body, template.CategoryID, id,
+	)
+	if isForeignKeyViolation(err) {
+		return storage.ErrCategoryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrTemplateNotFound
+	}
+	return nil
+}
+
+func (t *templateRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.templates.Delete"
+
+	res, err := t.db.Exec(ctx, `DELETE FROM answer_templates WHERE uuid = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrTemplateNotFound
+	}
+	return nil
+}
+
+func scanTemplate(row pgx.Row) (domain.AnswerTemplate, error) {
+	var template domain.AnswerTemplate
+	err := row.Scan(
+		&template.ID,
+		&template.Title,
+		&template.Body,
+		&template.CategoryID,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	return template, err
+}
+
+// isForeignKeyViolation reports whether err comes from a missing referenced row, here the category.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -0,0 +1,318 @@
+package pg
+
+import (
+	"complaint_server/internal/domain"
+	"complaint_server/internal/repository"
+	"complaint_server/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type webhookRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepo(db *Storage) repository.WebhookRepository {
+	return &webhookRepo{db: db.db}
+}
+
+const subscriptionColumns = `uuid, url, secret, event_types, category_id, active, created_at, updated_at`
+
+const deliveryColumns = `uuid, subscription_id, event_id, event_type, payload, status, attempts,
+	next_attempt_at, last_error, response_code, created_at, delivered_at`
+
+func (w *webhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (uuid.UUID, error) {
+	const op = "storage.webhooks.CreateSubscription"
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types, category_id, active)
+		VALUES ($1, $2, $3, $4, $5) RETURNING uuid`
+	var id uuid.UUID
+	err := w.db.QueryRow(ctx, query,
+		subscription.URL,
+		subscription.Secret,
+		eventTypesToStrings(subscription.EventTypes),
+		subscription.CategoryID,
+		subscription.Active,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (w *webhookRepo) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	const op = "storage.webhooks.GetSubscriptions"
+
+	rows, err := w.db.Query(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var subscriptions []domain.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return subscriptions, nil
+}
+
+func (w *webhookRepo) GetSubscriptionByID(ctx context.Context, id uuid.UUID) (domain.WebhookSubscription, error) {
+	const op = "storage.webhooks.GetSubscriptionByID"
+
+	row := w.db.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE uuid = $1`, id)
+	subscription, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WebhookSubscription{}, storage.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return domain.WebhookSubscription{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return subscription, nil
+}
+
+func (w *webhookRepo) UpdateSubscription(ctx context.Context, id uuid.UUID, subscription domain.WebhookSubscription) error {
+	const op = "storage.webhooks.UpdateSubscription"
+
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, secret = $2, event_types = $3, category_id = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE uuid = $6`
+	res, err := w.db.Exec(ctx, query,
+		subscription.URL,
+		subscription.Secret,
+		eventTypesToStrings(subscription.EventTypes),
+		subscription.CategoryID,
+		subscription.Active,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (w *webhookRepo) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.webhooks.DeleteSubscription"
+
+	res, err := w.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE uuid = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (w *webhookRepo) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (uuid.UUID, error) {
+	const op = "storage.webhooks.CreateDelivery"
+
+	// An event may be published more than once, the unique key keeps a single delivery per subscription.
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING uuid`
+	var id uuid.UUID
+	err := w.db.QueryRow(ctx, query,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// ClaimDueDeliveries picks pending deliveries whose attempt time has come and pushes their
+// next attempt forward by lease, so that concurrent workers do not send the same delivery twice.
+func (w *webhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	const op = "storage.webhooks.ClaimDueDeliveries"
+
+	query := `
+		WITH due AS (
+			SELECT uuid FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		FROM due
+		WHERE d.uuid = due.uuid
+		RETURNING d.uuid, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.last_error, d.response_code, d.created_at, d.delivered_at`
+
+	rows, err := w.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return deliveries, nil
+}
+
+func (w *webhookRepo) MarkDelivered(ctx context.Context, id uuid.UUID, attempts int, responseCode int) error {
+	const op = "storage.webhooks.MarkDelivered"
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = $1, response_code = $2, last_error = NULL,
+			delivered_at = CURRENT_TIMESTAMP
+		WHERE uuid = $3`
+	if _, err := w.db.Exec(ctx, query, attempts, responseCode, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (w *webhookRepo) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, responseCode int, lastError string, retryIn time.Duration, status domain.DeliveryStatus) error {
+	const op = "storage.webhooks.MarkFailed"
+
+	code := sql.NullInt32{Int32: int32(responseCode), Valid: responseCode != 0}
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4,
+			next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $5)
+		WHERE uuid = $6`
+	if _, err := w.db.Exec(ctx, query, status, attempts, code, lastError, retryIn.Seconds(), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (w *webhookRepo) GetDeliveries(ctx context.Context, subscriptionID *uuid.UUID, status domain.DeliveryStatus, limit int) ([]domain.WebhookDelivery, error) {
+	const op = "storage.webhooks.GetDeliveries"
+
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE ($1::uuid IS NULL OR subscription_id = $1)
+		  AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3`
+
+	rows, err := w.db.Query(ctx, query, subscriptionID, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a delivery again with a fresh attempt budget, so a dead one isn't given up
+// after its next failure.
+func (w *webhookRepo) Redeliver(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.webhooks.Redeliver"
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, last_error = NULL, response_code = NULL, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE uuid = $1`
+	res, err := w.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func scanSubscription(row pgx.Row) (domain.WebhookSubscription, error) {
+	var subscription domain.WebhookSubscription
+	var eventTypes []string
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Secret,
+		&eventTypes,
+		&subscription.CategoryID,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	for _, t := range eventTypes {
+		subscription.EventTypes = append(subscription.EventTypes, domain.EventType(t))
+	}
+	return subscription, nil
+}
+
+func scanDelivery(row pgx.Row) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&delivery.ResponseCode,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	return delivery, err
+}
+
+func eventTypesToStrings(eventTypes []domain.EventType) []string {
+	result := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		result = append(result, string(t))
+	}
+	return result
+}
@@ -4,21 +4,21 @@ import (
 	"complaint_server/internal/config"
 	"complaint_server/internal/shared/logger/sl"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"log/slog"
+	"strconv"
 	"time"
 )
 
-func NewClient(ctx context.Context, cfg *config.Config, log *slog.Logger) (*redis.Client, error) {
-	dialTimeout, err := time.ParseDuration(cfg.RedisClient.DialTimeout)
-	if err != nil {
-		log.Error("invalid REDIS_DIAL_TIMEOUT: %v", err)
-	}
-
-	timeout, err := time.ParseDuration(cfg.RedisClient.Timeout)
-	if err != nil {
-		log.Error("invalid REDIS_TIMEOUT: %v", err)
-	}
+// NewClient connects to Redis behind a circuit breaker. Redis is optional: when it can't be
+// reached the breaker starts open and the app runs on its in-memory fallbacks until the
+// breaker's background probe gets through.
+func NewClient(ctx context.Context, cfg *config.Config, log *slog.Logger) (*redis.Client, *Breaker) {
+	dialTimeout := config.ParseDuration(log, "REDIS_DIAL_TIMEOUT", cfg.RedisClient.DialTimeout, 5*time.Second)
+	timeout := config.ParseDuration(log, "REDIS_TIMEOUT", cfg.RedisClient.Timeout, 10*time.Second)
 
 	db := redis.NewClient(&redis.Options{
 		Addr:         cfg.RedisClient.Addr,
@@ -30,23 +30,109 @@ func NewClient(ctx context.Context, cfg *config.Config, log *slog.Logger) (*redi
 		ReadTimeout:  timeout,
 		WriteTimeout: timeout,
 	})
+	breaker := newBreaker(db, cfg.RedisClient.BreakerThreshold,
+		config.ParseDuration(log, "REDIS_PROBE_INTERVAL", cfg.RedisClient.ProbeInterval, 5*time.Second), log)
 	log.Info(db.String())
-	if err := db.Ping(ctx).Err(); err != nil {
-		log.Error("failed to connect to redis server", sl.Err(err))
-		return nil, err
+	if err := db.Ping(context.WithValue(ctx, probeKey{}, true)).Err(); err != nil {
+		log.Error("failed to connect to redis server, starting without it", sl.Err(err))
+		breaker.trip(err)
 	} else {
 		log.Info("connected to Redis successfully")
 	}
 
-	return db, nil
+	return db, breaker
+}
+
+// Tags name what a cached HTTP response was built from; a write invalidates every response
+// carrying the tag of the data it changed.
+const (
+	TagCategories = "categories"
+	TagComplaints = "complaints"
+)
+
+// BoardGenerationKey holds the generation of the cached public board pages. Pages are cached per
+// page and filter, so a write that changes what the board shows drops them all at once by
+// moving to a new generation.
+const BoardGenerationKey = "cache:/public/complaints:generation"
+
+// TagKey is where the current version of a cache tag is kept.
+func TagKey(tag string) string {
+	return "cache:tag:" + tag
 }
 
 type Cache interface {
 	Delete(ctx context.Context, key string) error
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	// Load returns the value cached under key, or caches and returns what load produces. A Set
+	// or Delete of the key while load runs wins, so a value read before a write isn't cached after it.
+	Load(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (string, error)) (string, error)
+	// Invalidate bumps the version of each tag, so responses cached under the old one are no longer found.
+	Invalidate(ctx context.Context, tags ...string) error
 }
 
+// Fetch is cache-aside for JSON values: it returns what is cached under key, or loads, caches
+// and returns it. When the cache is unavailable it just loads.
+func Fetch[T any](ctx context.Context, cache Cache, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	loaded := false
+	data, err := cache.Load(ctx, key, ttl, func(ctx context.Context) (string, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return "", err
+		}
+		result, loaded = value, true
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	})
+	if loaded {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return load(ctx)
+	}
+	return result, nil
+}
+
+// Values are kept under key@version and the version under key:version. Set and Delete bump the
+// version, and Load only stores at the version it read, so a racing write always wins.
+var (
+	getScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1] .. ':version') or '0'
+return {version, redis.call('GET', KEYS[1] .. '@' .. version)}`)
+
+	setScript = redis.NewScript(`
+local version = redis.call('INCR', KEYS[1] .. ':version')
+redis.call('DEL', KEYS[1] .. '@' .. (version - 1))
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1] .. '@' .. version, ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1] .. '@' .. version, ARGV[1])
+end
+return version`)
+
+	storeScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1] .. ':version') or '0'
+if version ~= ARGV[3] then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1] .. '@' .. version, ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1] .. '@' .. version, ARGV[1])
+end
+return 1`)
+
+	deleteScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1] .. ':version') or '0'
+redis.call('DEL', KEYS[1] .. '@' .. version)
+return redis.call('INCR', KEYS[1] .. ':version')`)
+)
+
+// RedisCache is the shared layer of the cache.
 type RedisCache struct {
 	client *redis.Client
 }
@@ -56,13 +142,71 @@ func NewRedisCache(client *redis.Client) *RedisCache {
 }
 
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	_, err := r.delete(ctx, key)
+	return err
 }
 
 func (r *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
-	return r.client.Set(ctx, key, value, ttl).Err()
+	_, err := r.set(ctx, key, value, ttl)
+	return err
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	value, _, err := r.get(ctx, key)
+	return value, err
+}
+
+func (r *RedisCache) Load(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (string, error)) (string, error) {
+	value, version, err := r.get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	fresh, loadErr := load(ctx)
+	if loadErr != nil {
+		return "", loadErr
+	}
+	if errors.Is(err, redis.Nil) {
+		_, _ = r.store(ctx, key, fresh, ttl, version)
+	}
+	return fresh, nil
+}
+
+func (r *RedisCache) Invalidate(ctx context.Context, tags ...string) error {
+	pipe := r.client.Pipeline()
+	for _, tag := range tags {
+		pipe.Incr(ctx, TagKey(tag))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// get returns the value of key and its version; on a miss the error is redis.Nil and the
+// version is the one a Load has to store at.
+func (r *RedisCache) get(ctx context.Context, key string) (string, int64, error) {
+	reply, err := getScript.Run(ctx, r.client, []string{key}).Slice()
+	if err != nil {
+		return "", 0, err
+	}
+	version, _ := strconv.ParseInt(fmt.Sprint(reply[0]), 10, 64)
+	value, ok := reply[1].(string)
+	if !ok {
+		l2Misses.Add(1)
+		return "", version, redis.Nil
+	}
+	l2Hits.Add(1)
+	return value, version, nil
+}
+
+func (r *RedisCache) set(ctx context.Context, key string, value any, ttl time.Duration) (int64, error) {
+	return setScript.Run(ctx, r.client, []string{key}, value, ttl.Milliseconds()).Int64()
+}
+
+// store caches value only if key is still at version.
+func (r *RedisCache) store(ctx context.Context, key string, value string, ttl time.Duration, version int64) (bool, error) {
+	stored, err := storeScript.Run(ctx, r.client, []string{key}, value, ttl.Milliseconds(), strconv.FormatInt(version, 10)).Int()
+	return stored == 1, err
+}
+
+func (r *RedisCache) delete(ctx context.Context, key string) (int64, error) {
+	return deleteScript.Run(ctx, r.client, []string{key}).Int64()
 }
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis starts an in-process Redis and returns a client connected to it.
+func testRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestRedisCacheSetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	_, client := testRedis(t)
+	cache := NewRedisCache(client)
+
+	if _, err := cache.Get(ctx, "k"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Get of a missing key = %v, want redis.Nil", err)
+	}
+	if err := cache.Set(ctx, "k", "v1", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Set(ctx, "k", "v2", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, err := cache.Get(ctx, "k"); err != nil || value != "v2" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+	if err := cache.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := cache.Get(ctx, "k"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Get after Delete = %v, want redis.Nil", err)
+	}
+}
+
+func TestRedisCacheSetExpires(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	cache := NewRedisCache(client)
+
+	if err := cache.Set(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	mr.FastForward(2 * time.Minute)
+
+	if _, err := cache.Get(ctx, "k"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Get after the ttl = %v, want redis.Nil", err)
+	}
+}
+
+func TestRedisCacheLoadCachesResult(t *testing.T) {
+	ctx := context.Background()
+	_, client := testRedis(t)
+	cache := NewRedisCache(client)
+
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "v", nil
+	}
+	for range 2 {
+		if value, err := cache.Load(ctx, "k", time.Minute, load); err != nil || value != "v" {
+			t.Fatalf("Load = %q, %v", value, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("load ran %d times, want 1", loads)
+	}
+}
+
+func TestRedisCacheLoadLosesToRacingWrite(t *testing.T) {
+	writes := map[string]func(ctx context.Context, cache *RedisCache) error{
+		"set": func(ctx context.Context, cache *RedisCache) error {
+			return cache.Set(ctx, "k", "new", time.Minute)
+		},
+		"delete": func(ctx context.Context, cache *RedisCache) error {
+			return cache.Delete(ctx, "k")
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, client := testRedis(t)
+			cache := NewRedisCache(client)
+
+			value, err := cache.Load(ctx, "k", time.Minute, func(ctx context.Context) (string, error) {
+				// The write lands after load read the database but before Load stores the result.
+				return "old", write(ctx, cache)
+			})
+			if err != nil || value != "old" {
+				t.Fatalf("Load = %q, %v", value, err)
+			}
+			if value, _ := cache.Get(ctx, "k"); value == "old" {
+				t.Fatal("Load cached a value read before a racing write")
+			}
+		})
+	}
+}
+
+func TestRedisCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	cache := NewRedisCache(client)
+
+	if err := cache.Invalidate(ctx, TagCategories, TagComplaints); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if err := cache.Invalidate(ctx, TagComplaints); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	for tag, want := range map[string]string{TagCategories: "1", TagComplaints: "2"} {
+		if got, _ := mr.Get(TagKey(tag)); got != want {
+			t.Errorf("%s = %q, want %q", TagKey(tag), got, want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	ctx := context.Background()
+	mr, client := testRedis(t)
+	cache := NewRedisCache(client)
+
+	loads := 0
+	load := func(context.Context) (item, error) {
+		loads++
+		return item{Name: "Столовая"}, nil
+	}
+	for range 2 {
+		got, err := Fetch(ctx, cache, "k", time.Minute, load)
+		if err != nil || got.Name != "Столовая" {
+			t.Fatalf("Fetch = %+v, %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("load ran %d times with a warm cache, want 1", loads)
+	}
+
+	// Without Redis Fetch still answers from the loader.
+	mr.Close()
+	if got, err := Fetch(ctx, cache, "k", time.Minute, load); err != nil || got.Name != "Столовая" {
+		t.Fatalf("Fetch without redis = %+v, %v", got, err)
+	}
+	if loads != 2 {
+		t.Fatalf("load ran %d times, want 2", loads)
+	}
+}
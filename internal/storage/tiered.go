@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"complaint_server/internal/config"
+	"complaint_server/internal/shared/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	l1Hits        = expvar.NewInt("cache_l1_hits")
+	l1Misses      = expvar.NewInt("cache_l1_misses")
+	l1Evictions   = expvar.NewInt("cache_l1_evictions")
+	l1Size        = expvar.NewInt("cache_l1_size")
+	l2Hits        = expvar.NewInt("cache_l2_hits")
+	l2Misses      = expvar.NewInt("cache_l2_misses")
+	invalidations = expvar.NewInt("cache_invalidations_received")
+)
+
+// invalidation tells every replica that key moved to version.
+type invalidation struct {
+	Key     string `json:"key"`
+	Version int64  `json:"version"`
+}
+
+// TieredCache keeps recently read values in process (L1) in front of RedisCache (L2). Writes go
+// to Redis and are announced on CACHE_INVALIDATION_CHANNEL so the other replicas drop their
+// copies; an announcement missed during a reconnect is bounded by CACHE_L1_TTL.
+// While Redis is unavailable L1 is the whole cache. Writes then only drop local copies and are
+// remembered, and Resync applies them to Redis once it is back.
+type TieredCache struct {
+	l1      *lru
+	l2      *RedisCache
+	client  *redis.Client
+	channel string
+	log     *slog.Logger
+
+	mu          sync.Mutex
+	pendingKeys map[string]struct{}
+	pendingTags map[string]struct{}
+}
+
+func NewTieredCache(client *redis.Client, cfg *config.Config, log *slog.Logger) *TieredCache {
+	return &TieredCache{
+		l1:      newLRU(cfg.Cache.L1Size, config.ParseDuration(log, "CACHE_L1_TTL", cfg.Cache.L1TTL, 30*time.Second)),
+		l2:      NewRedisCache(client),
+		client:  client,
+		channel: cfg.Cache.Channel,
+		log:     log.With(slog.String("component", "storage/cache")),
+
+		pendingKeys: make(map[string]struct{}),
+		pendingTags: make(map[string]struct{}),
+	}
+}
+
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, ok := t.l1.get(key); ok {
+		l1Hits.Add(1)
+		return value, nil
+	}
+	l1Misses.Add(1)
+
+	value, version, err := t.l2.get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	t.l1.put(key, value, version, 0)
+	return value, nil
+}
+
+func (t *TieredCache) Load(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (string, error)) (string, error) {
+	if value, ok := t.l1.get(key); ok {
+		l1Hits.Add(1)
+		return value, nil
+	}
+	l1Misses.Add(1)
+
+	value, version, err := t.l2.get(ctx, key)
+	if err == nil {
+		t.l1.put(key, value, version, ttl)
+		return value, nil
+	}
+	fresh, loadErr := load(ctx)
+	if loadErr != nil {
+		return "", loadErr
+	}
+	if errors.Is(err, redis.Nil) {
+		if stored, err := t.l2.store(ctx, key, fresh, ttl, version); err == nil && stored {
+			t.l1.put(key, fresh, version, ttl)
+		}
+	} else {
+		t.l1.put(key, fresh, t.l1.version(key), ttl)
+	}
+	return fresh, nil
+}
+
+func (t *TieredCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	version, err := t.l2.set(ctx, key, value, ttl)
+	if err != nil {
+		t.deferWrite(key)
+		return nil
+	}
+	t.l1.invalidate(key, version)
+	t.announce(ctx, key, version)
+	return nil
+}
+
+func (t *TieredCache) Delete(ctx context.Context, key string) error {
+	version, err := t.l2.delete(ctx, key)
+	if err != nil {
+		t.deferWrite(key)
+		return nil
+	}
+	t.l1.invalidate(key, version)
+	t.announce(ctx, key, version)
+	return nil
+}
+
+func (t *TieredCache) Invalidate(ctx context.Context, tags ...string) error {
+	if err := t.l2.Invalidate(ctx, tags...); err != nil {
+		t.mu.Lock()
+		for _, tag := range tags {
+			t.pendingTags[tag] = struct{}{}
+		}
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// deferWrite drops the local copy of a key whose write didn't reach Redis and keeps it for Resync.
+func (t *TieredCache) deferWrite(key string) {
+	t.l1.drop(key)
+	t.mu.Lock()
+	t.pendingKeys[key] = struct{}{}
+	t.mu.Unlock()
+}
+
+// Resync applies the writes made while Redis was unavailable; Redis may still hold values they replaced.
+func (t *TieredCache) Resync(ctx context.Context) {
+	t.mu.Lock()
+	keys, tags := t.pendingKeys, t.pendingTags
+	t.pendingKeys, t.pendingTags = make(map[string]struct{}), make(map[string]struct{})
+	t.mu.Unlock()
+
+	for key := range keys {
+		_ = t.Delete(ctx, key)
+	}
+	if len(tags) > 0 {
+		_ = t.Invalidate(ctx, slices.Collect(maps.Keys(tags))...)
+	}
+	if len(keys) > 0 || len(tags) > 0 {
+		t.log.Info("cache resynced after redis outage", slog.Int("keys", len(keys)), slog.Int("tags", len(tags)))
+	}
+}
+
+func (t *TieredCache) announce(ctx context.Context, key string, version int64) {
+	payload, _ := json.Marshal(invalidation{Key: key, Version: version})
+	if err := t.client.Publish(ctx, t.channel, payload).Err(); err != nil {
+		t.log.Warn("failed to announce cache invalidation", slog.String("key", key), sl.Err(err))
+	}
+}
+
+// Run drops L1 entries that other replicas announce as changed.
+func (t *TieredCache) Run(ctx context.Context) {
+	pubsub := t.client.Subscribe(ctx, t.channel)
+	defer pubsub.Close()
+
+	t.log.Info("cache invalidation listener started", slog.String("channel", t.channel))
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			t.log.Info("cache invalidation listener stopped")
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				t.log.Error("failed to decode cache invalidation", sl.Err(err))
+				continue
+			}
+			invalidations.Add(1)
+			t.l1.invalidate(event.Key, event.Version)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"complaint_server/internal/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testChannel = "cache:invalidations"
+
+func testTieredCache(t *testing.T, mr *miniredis.Miniredis) *TieredCache {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cfg := &config.Config{Cache: config.Cache{L1Size: 100, L1TTL: "1m", Channel: testChannel}}
+	return NewTieredCache(client, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// eventually retries check until it holds or five seconds have passed.
+func eventually(t *testing.T, check func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if check() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTieredCacheServesFromL1(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	cache := testTieredCache(t, mr)
+
+	if err := cache.Set(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, err := cache.Get(ctx, "k"); err != nil || value != "v" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+
+	// With Redis gone the copy read above is still served.
+	mr.Close()
+	if value, err := cache.Get(ctx, "k"); err != nil || value != "v" {
+		t.Fatalf("Get from L1 = %q, %v", value, err)
+	}
+}
+
+func TestTieredCacheInvalidatesOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mr := miniredis.RunT(t)
+	writer, reader := testTieredCache(t, mr), testTieredCache(t, mr)
+
+	go reader.Run(ctx)
+	if !eventually(t, func() bool { return mr.PubSubNumSub(testChannel)[testChannel] == 1 }) {
+		t.Fatal("the listener didn't subscribe")
+	}
+
+	if err := writer.Set(ctx, "k", "v1", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, err := reader.Get(ctx, "k"); err != nil || value != "v1" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+	if err := writer.Set(ctx, "k", "v2", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !eventually(t, func() bool {
+		value, _ := reader.Get(ctx, "k")
+		return value == "v2"
+	}) {
+		t.Fatal("the reader kept serving its copy after another replica changed the key")
+	}
+}
+
+func TestTieredCacheResyncsWritesMadeWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	cache := testTieredCache(t, mr)
+
+	if err := cache.Set(ctx, "k", "old", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := cache.Get(ctx, "k"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	addr := mr.Addr()
+	mr.Close()
+	if err := cache.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete without redis: %v", err)
+	}
+	if err := cache.Invalidate(ctx, TagComplaints); err != nil {
+		t.Fatalf("Invalidate without redis: %v", err)
+	}
+	if value, err := cache.Get(ctx, "k"); err == nil {
+		t.Fatalf("Get = %q after a local delete, want a miss", value)
+	}
+
+	// Redis comes back still holding the value the delete replaced.
+	if err := mr.StartAddr(addr); err != nil {
+		t.Fatal(err)
+	}
+	// The client backs off from dialing after failed attempts; Resync runs once a probe gets through.
+	if !eventually(t, func() bool { return cache.client.Ping(ctx).Err() == nil }) {
+		t.Fatal("the client didn't reconnect")
+	}
+	cache.Resync(ctx)
+
+	if _, err := NewRedisCache(cache.client).Get(ctx, "k"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Get from redis after Resync = %v, want redis.Nil", err)
+	}
+	if got, _ := mr.Get(TagKey(TagComplaints)); got != "1" {
+		t.Fatalf("%s = %q after Resync, want 1", TagKey(TagComplaints), got)
+	}
+}